@@ -0,0 +1,93 @@
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// asyncQueue writes entries to their outputs from a background goroutine.
+type asyncQueue struct {
+	mu      sync.RWMutex // guards drop and closed, and excludes sends while the queue is closed
+	drop    bool
+	closed  bool
+	entries chan *entry
+	done    chan struct{} // closed when the worker exits
+	dropped *uint64       // accessed atomically
+}
+
+// newAsyncQueue starts a worker that writes queued entries while holding writeMu, which must be the
+// writeMu of the Log that owns the queue. Dropped entries are counted in dropped.
+func newAsyncQueue(writeMu *sync.Mutex, size int, drop bool, dropped *uint64) *asyncQueue {
+	q := &asyncQueue{
+		drop:    drop,
+		dropped: dropped,
+		entries: make(chan *entry, size),
+		done:    make(chan struct{}),
+	}
+	go func() {
+		defer close(q.done)
+		for e := range q.entries {
+			if e.flushed != nil {
+				close(e.flushed)
+				continue
+			}
+			writeMu.Lock()
+			err := e.write() // there is no caller to return the error to
+			writeMu.Unlock()
+			e.release()
+			e.written(err)
+		}
+	}()
+	return q
+}
+
+// send queues e, or drops it if the queue is full and the drop policy is set. It returns false,
+// without queueing e, if the queue has been closed.
+func (q *asyncQueue) send(e *entry) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return false
+	}
+	if !q.drop {
+		q.entries <- e
+		return true
+	}
+	select {
+	case q.entries <- e:
+	default:
+		atomic.AddUint64(q.dropped, 1)
+		e.release()
+	}
+	return true
+}
+
+// flush waits until every entry queued before the call has been written.
+func (q *asyncQueue) flush() {
+	flushed := make(chan struct{})
+	q.mu.RLock()
+	if q.closed {
+		q.mu.RUnlock()
+		return
+	}
+	q.entries <- &entry{flushed: flushed}
+	q.mu.RUnlock()
+	<-flushed
+}
+
+// close writes any queued entries and stops the worker.
+func (q *asyncQueue) close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.entries)
+	}
+	q.mu.Unlock()
+	<-q.done
+}
+
+func (q *asyncQueue) setDrop(drop bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.drop = drop
+}
@@ -0,0 +1,85 @@
+package log_test
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Syncbak-Git/log"
+)
+
+// gatedWriter blocks every write until the gate is closed.
+type gatedWriter struct {
+	gate chan struct{}
+	buff syncBuffer
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	<-w.gate
+	return w.buff.Write(p)
+}
+
+func TestAsync(t *testing.T) {
+	before := runtime.NumGoroutine()
+	var buff syncBuffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetAsync(10)
+	for i := 0; i < 1000; i++ {
+		l.Info("entry %d", i)
+	}
+	if err := l.Flush(); err != nil {
+		t.Errorf("Flush failed: %s", err)
+	}
+	if c := strings.Count(buff.String(), "\n"); c != 1000 {
+		t.Errorf("Flush wrote %d entries, want 1000", c)
+	}
+	l.Info("last")
+	if err := l.Close(); err != nil {
+		t.Errorf("Close failed: %s", err)
+	}
+	if !strings.HasSuffix(buff.String(), "\tlast\n") {
+		t.Errorf("Close didn't write queued entry: %s", buff.String())
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("Goroutine leaked: %d before, %d after Close", before, after)
+	}
+	l.Info("sync")
+	if !strings.HasSuffix(buff.String(), "\tsync\n") {
+		t.Errorf("Entry after Close wasn't written synchronously: %s", buff.String())
+	}
+}
+
+func TestAsyncDropPolicy(t *testing.T) {
+	w := &gatedWriter{gate: make(chan struct{})}
+	l := log.NewLog()
+	l.SetOutput(w)
+	l.SetAsyncDropPolicy(true)
+	l.SetAsync(2)
+	done := make(chan struct{})
+	go func() {
+		// at most one entry is being written and two are queued; the rest are dropped
+		for i := 0; i < 10; i++ {
+			l.Info("entry %d", i)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Logging blocked with drop policy set")
+	}
+	if d := l.Dropped(); d < 7 {
+		t.Errorf("Dropped %d entries, want at least 7", d)
+	}
+	close(w.gate)
+	l.Close()
+	if c := strings.Count(w.buff.String(), "\n"); uint64(c)+l.Dropped() != 10 {
+		t.Errorf("Wrote %d and dropped %d entries, want 10 in total", c, l.Dropped())
+	}
+}
@@ -0,0 +1,163 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// batchWriter collects entries and writes them to w together. See SetBatching.
+type batchWriter struct {
+	maxEntries int
+	maxDelay   time.Duration
+
+	mu      sync.Mutex
+	w       io.Writer
+	buf     bytes.Buffer
+	entries int
+	first   time.Time   // when the oldest buffered entry was written
+	timer   *time.Timer // flushes the batch after maxDelay, if nothing else does
+	config  rotateConfig
+}
+
+func newBatchWriter(w io.Writer, maxEntries int, maxDelay time.Duration) *batchWriter {
+	return &batchWriter{w: w, maxEntries: maxEntries, maxDelay: maxDelay, config: rotateConfig{now: time.Now}}
+}
+
+// Write adds p, which is a single entry, to the batch, and writes the batch once it holds maxEntries
+// entries or its oldest entry is maxDelay old.
+func (b *batchWriter) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.config.now()
+	if b.entries == 0 {
+		b.first = now
+		if b.maxDelay > 0 {
+			b.timer = time.AfterFunc(b.maxDelay, b.flushLate)
+		}
+	}
+	b.buf.Write(p)
+	b.entries++
+	if b.entries >= b.maxEntries || (b.maxDelay > 0 && now.Sub(b.first) >= b.maxDelay) {
+		if err := b.flush(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// flushLate writes the batch when its timer fires, reporting an error to the Log's error handler since
+// there is no logging call to return it.
+func (b *batchWriter) flushLate() {
+	b.mu.Lock()
+	err := b.flush()
+	onError := b.config.onError
+	b.mu.Unlock()
+	if err != nil && onError != nil {
+		onError(err)
+	}
+}
+
+// flush writes the batch to w, and flushes w if it buffers too. b.mu must be held.
+func (b *batchWriter) flush() error {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	var err error
+	if b.entries > 0 {
+		_, err = b.w.Write(b.buf.Bytes())
+		b.buf.Reset()
+		b.entries = 0
+	}
+	return errors.Join(err, flushWriters(b.w))
+}
+
+func (b *batchWriter) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.flush()
+}
+
+func (b *batchWriter) Sync() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return errors.Join(b.flush(), syncWriters(b.w))
+}
+
+func (b *batchWriter) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	err := b.flush()
+	if c, ok := b.w.(io.Closer); ok {
+		err = errors.Join(err, c.Close())
+	}
+	return err
+}
+
+// reset writes the batch to the current writer and closes it, and then batches entries for w. It is used
+// by Reopen.
+func (b *batchWriter) reset(w io.Writer) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	err := b.flush()
+	if c, ok := b.w.(io.Closer); ok {
+		err = errors.Join(err, c.Close())
+	}
+	b.w = w
+	configureOutput(w, b.config)
+	return err
+}
+
+func (b *batchWriter) configure(c rotateConfig) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.config = c
+	configureOutput(b.w, c)
+}
+
+// SetBatching makes l collect the entries it writes to its output and write them together, once
+// maxEntries have been collected or the oldest of them was written maxDelay ago, whichever comes
+// first, to save a write to the connection for each entry when logging heavily with SetTCPOutput. A
+// batch is also written by Flush, Sync and Close, and immediately after a FATAL or PANIC entry, since
+// the process is about to die. With SetAsync, entries are batched as the background goroutine writes
+// them. An error writing a batch is returned by the logging call that completed it, or passed to the
+// error handler set by SetErrorHandler if the batch is written because maxDelay has passed.
+//
+// Batching applies to the output set before the call, and is removed by setting another one. It is
+// ignored for outputs that must receive each entry separately: the syslog, event log and GELF outputs,
+// a Sink, and outputs combined by AddOutput. A maxEntries of 1 or less turns batching off, writing any
+// collected entries, and a maxDelay of zero or less only writes a batch when it is full.
+func (l *Log) SetBatching(maxEntries int, maxDelay time.Duration) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.writeMu.Lock()
+	defer l.writeMu.Unlock()
+	w := l.output
+	if b, ok := w.(*batchWriter); ok {
+		b.Flush()
+		w = b.w
+	}
+	switch w.(type) {
+	case levelWriter, sinkOutput, *gelfOutput:
+		return
+	}
+	if maxEntries <= 1 {
+		l.output = w
+		return
+	}
+	l.output = newBatchWriter(w, maxEntries, maxDelay)
+	l.configureOutputs()
+}
+
+func (b *batchWriter) describe() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return fmt.Sprintf("%s, batched by %d", describeOutput(b.w), b.maxEntries)
+}
@@ -0,0 +1,102 @@
+package log_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Syncbak-Git/log"
+)
+
+// writeCounter counts the writes made to it.
+type writeCounter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *writeCounter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestSetBatching(t *testing.T) {
+	var w writeCounter
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+	l := log.NewLog()
+	l.SetOutput(&w)
+	l.SetTimestamp(func() string { return "ts" })
+	l.SetClock(clock.Now)
+	l.SetBatching(3, time.Hour)
+
+	l.Info("one")
+	l.Info("two")
+	if w.writes != 0 {
+		t.Fatalf("Expected the entries to be held, got %d writes", w.writes)
+	}
+	l.Info("three")
+	if w.writes != 1 || w.String() != "ts\tINFO\tone\nts\tINFO\ttwo\nts\tINFO\tthree\n" {
+		t.Errorf("Expected a full batch to be written at once, got %d writes of %q", w.writes, w.String())
+	}
+
+	l.Info("four")
+	clock.Advance(time.Hour)
+	l.Info("five")
+	if w.writes != 2 || !strings.HasSuffix(w.String(), "four\nts\tINFO\tfive\n") {
+		t.Errorf("Expected an old batch to be written, got %d writes of %q", w.writes, w.String())
+	}
+
+	l.Info("six")
+	if err := l.Flush(); err != nil || w.writes != 3 || !strings.HasSuffix(w.String(), "six\n") {
+		t.Errorf("Expected Flush to write the batch, got %d writes, %v", w.writes, err)
+	}
+
+	l.SetExitFunc(func(int) {})
+	l.Info("seven")
+	l.Fatal("dying")
+	if w.writes != 5 || !strings.Contains(w.String(), "\tFATAL\tdying") {
+		t.Errorf("Expected a FATAL entry to be written immediately, got %d writes", w.writes)
+	}
+}
+
+func TestSetBatchingDelay(t *testing.T) {
+	var buff syncBuffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetBatching(100, 10*time.Millisecond)
+	l.Info("late")
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(buff.String(), "late") {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the batch to be written after its delay")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSetBatchingReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := log.NewFileLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.SetBatching(10, 0)
+	l.Info("before")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("after")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	old, _ := os.ReadFile(path + ".1")
+	cur, _ := os.ReadFile(path)
+	if !strings.Contains(string(old), "before") || !strings.Contains(string(cur), "after") || strings.Contains(string(cur), "before") {
+		t.Errorf("got %q in the old file and %q in the new one", old, cur)
+	}
+}
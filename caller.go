@@ -0,0 +1,52 @@
+package log
+
+import (
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// pkgPrefix prefixes the names of all functions in this package.
+var pkgPrefix = reflect.TypeOf(Log{}).PkgPath() + "."
+
+// caller returns the file basename and line number of the first function on the stack that is
+// outside this package, ie. the call site of the logging call, or an empty string if there is none.
+// Skipping by package rather than a fixed frame count handles the package-level wrappers and any
+// helpers that log on the caller's behalf.
+func caller() string {
+	var pcs [32]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs[:])])
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, pkgPrefix) {
+			return filepath.Base(f.File) + ":" + strconv.Itoa(f.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// stack returns the stack trace of the calling goroutine, starting at the first function outside this
+// package, with a line for each function followed by an indented line for its file and line number,
+// like the frames of debug.Stack.
+func stack() string {
+	var pcs [64]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs[:])])
+	var b strings.Builder
+	inPkg := true
+	for {
+		f, more := frames.Next()
+		if inPkg = inPkg && strings.HasPrefix(f.Function, pkgPrefix); !inPkg {
+			if b.Len() > 0 {
+				b.WriteByte('\n')
+			}
+			b.WriteString(f.Function + "\n\t" + f.File + ":" + strconv.Itoa(f.Line))
+		}
+		if !more {
+			return b.String()
+		}
+	}
+}
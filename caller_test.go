@@ -0,0 +1,84 @@
+package log_test
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func TestIncludeCaller(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.Info("no caller")
+	if strings.Contains(buff.String(), "caller=") {
+		t.Errorf("Caller included by default: %s", buff.String())
+	}
+
+	buff.Reset()
+	l.SetIncludeCaller(true)
+	_, _, line, _ := runtime.Caller(0)
+	l.Info("private")
+	if want := fmt.Sprintf("\tprivate\tcaller=caller_test.go:%d\n", line+1); !strings.HasSuffix(buff.String(), want) {
+		t.Errorf("got %q, want suffix %q", buff.String(), want)
+	}
+
+	buff.Reset()
+	l.WithFields(map[string]interface{}{"a": 1}).Error("child")
+	_, _, line, _ = runtime.Caller(0)
+	if want := fmt.Sprintf("\tchild\ta=1 caller=caller_test.go:%d\n", line-1); !strings.HasSuffix(buff.String(), want) {
+		t.Errorf("got %q, want suffix %q", buff.String(), want)
+	}
+
+	buff.Reset()
+	log.SetOutput(&buff)
+	log.SetLogLevel(log.LevelAll)
+	log.SetIncludeCaller(true)
+	defer log.SetIncludeCaller(false)
+	_, _, line, _ = runtime.Caller(0)
+	log.Warning("global")
+	if want := fmt.Sprintf("\tglobal\tcaller=caller_test.go:%d\n", line+1); !strings.HasSuffix(buff.String(), want) {
+		t.Errorf("got %q, want suffix %q", buff.String(), want)
+	}
+}
+
+func BenchmarkLog_caller(b *testing.B) {
+	l := log.NewLog()
+	l.SetOutput(nopWriter{})
+	l.SetIncludeCaller(true)
+	for n := 0; n < b.N; n++ {
+		l.Error("Hello world")
+	}
+}
+
+func TestSetStackTraceLevel(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetFatalAsError(true)
+	l.Error("no stack")
+	l.Fatal("with stack")
+	lines := strings.SplitN(buff.String(), "\n", 2)
+	if strings.Contains(lines[0], "stack=") {
+		t.Errorf("ERROR entry has a stack: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], "\tFATAL\twith stack\tstack=github.com/Syncbak-Git/log_test.TestSetStackTraceLevel\n\t") ||
+		!strings.Contains(lines[1], "caller_test.go:") {
+		t.Errorf("FATAL entry doesn't start the stack at the call site: %s", lines[1])
+	}
+
+	buff.Reset()
+	l.SetFormat(&log.TextFormatter{EscapeNewlines: true})
+	l.SetStackTraceLevel(log.LevelError)
+	l.Error("escaped")
+	l.Fatal("no stack")
+	lines = strings.Split(strings.TrimSuffix(buff.String(), "\n"), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "stack=github.com/Syncbak-Git/log_test.TestSetStackTraceLevel\\n\t") ||
+		strings.Contains(lines[1], "stack=") {
+		t.Errorf("Unexpected entries: %q", lines)
+	}
+}
@@ -0,0 +1,43 @@
+package log
+
+import (
+	"io"
+	"os"
+)
+
+// ANSI escape sequences used to color the level of text entries.
+const (
+	colorGray   = "\x1b[90m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorRed    = "\x1b[31m"
+	colorReset  = "\x1b[0m"
+)
+
+// colorLevel returns level wrapped in the ANSI color for its name. Custom levels aren't colored.
+func colorLevel(level string) string {
+	var c string
+	switch level {
+	case "TRACE", "DEBUG":
+		c = colorGray
+	case "INFO":
+		c = colorGreen
+	case "WARNING":
+		c = colorYellow
+	case "ERROR", "FATAL", "PANIC":
+		c = colorRed
+	default:
+		return level
+	}
+	return c + level + colorReset
+}
+
+// isTerminal reports whether w is an *os.File connected to a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
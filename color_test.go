@@ -0,0 +1,63 @@
+package log_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func TestSetColor(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	l.Info("plain")
+	l.SetColorAuto()
+	l.Error("auto")
+	if strings.Contains(buff.String(), "\x1b") {
+		t.Errorf("Escape sequence written with color off: %q", buff.String())
+	}
+	buff.Reset()
+	l.SetColor(true)
+	l.Info("green")
+	l.Error("red")
+	l.Custom("TEST", "plain")
+	want := "ts\t\x1b[32mINFO\x1b[0m\tgreen\n" +
+		"ts\t\x1b[31mERROR\x1b[0m\tred\n" +
+		"ts\tTEST\tplain\n"
+	if got := buff.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	buff.Reset()
+	l.SetFormat(log.NewJSONFormatter())
+	l.SetColor(true)
+	l.Info("json")
+	if strings.Contains(buff.String(), "\x1b") {
+		t.Errorf("Escape sequence written to JSON: %q", buff.String())
+	}
+}
+
+func TestSetColorAutoFile(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "test.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := log.NewLog()
+	l.SetOutput(f)
+	l.SetColorAuto()
+	l.Warning("not a terminal")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(b, []byte("\x1b")) {
+		t.Errorf("Escape sequence written to a file: %q", b)
+	}
+}
@@ -0,0 +1,121 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Config describes the settings of a Log, as returned by (*Log).Config. It is meant for display, eg. by
+// an admin endpoint, and marshals to JSON with the Level written as its name.
+type Config struct {
+	Level        Level             `json:"level"`
+	LevelName    string            `json:"level_name"`    // Level.String()
+	Format       string            `json:"format"`        // "text", "json", "logfmt", "csv", "gelf", or the type of a custom Formatter
+	Output       string            `json:"output"`        // a description of the output, eg. "file /var/log/app.log"
+	LevelOutputs map[string]string `json:"level_outputs"` // descriptions of the outputs set by SetLevelOutput, by level
+	TimeFormat   string            `json:"time_format"`   // the layout set by SetTimeFormat, "custom" after SetTimestamp, or "none"
+	Prefix       string            `json:"prefix"`
+	Caller       bool              `json:"caller"`         // set by SetIncludeCaller
+	Color        bool              `json:"color"`          // set by SetColor
+	Async        bool              `json:"async"`          // set by SetAsync
+	AsyncDrop    bool              `json:"async_drop"`     // set by SetAsyncDropPolicy
+	Sampling     bool              `json:"sampling"`       // whether a Sampler is set
+	RateLimit    int               `json:"rate_limit"`     // entries per second set by SetRateLimit, or 0
+	Fields       int               `json:"fields"`         // the number of fields added by WithFields and With
+	StackLevels  Level             `json:"stack_levels"`   // set by SetStackTraceLevel
+	FatalAsError bool              `json:"fatal_as_error"` // set by SetFatalAsError
+}
+
+// Config returns a snapshot of l's settings. It is safe to call while l is being used.
+func (l *Log) Config() Config {
+	if l == nil {
+		return Config{LevelName: Level(LevelNone).String()}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	level := l.level()
+	c := Config{
+		Level:        level,
+		LevelName:    level.String(),
+		Format:       formatName(l.formatter),
+		Output:       describeOutput(l.output),
+		TimeFormat:   l.timeLayout,
+		Prefix:       l.prefix,
+		Caller:       l.withCaller,
+		Async:        l.async != nil,
+		AsyncDrop:    l.asyncDrop,
+		Sampling:     l.sampler != nil,
+		StackLevels:  l.stackLevels,
+		FatalAsError: l.fatalAsError,
+	}
+	if tf, ok := l.formatter.(*TextFormatter); ok {
+		c.Color = tf.Color
+	}
+	if len(l.pairs) > 0 {
+		fields := make(map[string]interface{}, len(l.fields)+len(l.pairs))
+		l.mergeFields(fields)
+		c.Fields = len(fields)
+	} else {
+		c.Fields = len(l.fields)
+	}
+	if l.noTimestamp {
+		c.TimeFormat = "none"
+	} else if l.timestamp != nil {
+		c.TimeFormat = "custom"
+	}
+	if l.limiter != nil {
+		c.RateLimit = int(l.limiter.rate)
+	}
+	if len(l.levelOutputs) > 0 {
+		c.LevelOutputs = make(map[string]string, len(l.levelOutputs))
+		for _, o := range l.levelOutputs {
+			c.LevelOutputs[o.mask.String()] = describeOutput(o.w)
+		}
+	}
+	return c
+}
+
+// formatName returns the name of f for Config.
+func formatName(f Formatter) string {
+	switch f.(type) {
+	case *TextFormatter:
+		return "text"
+	case *JSONFormatter:
+		return "json"
+	case *LogfmtFormatter:
+		return "logfmt"
+	case *CSVFormatter:
+		return "csv"
+	case *GELFFormatter:
+		return "gelf"
+	}
+	return fmt.Sprintf("%T", f)
+}
+
+// describer is implemented by the outputs of this package, to describe themselves for Config.
+type describer interface {
+	describe() string
+}
+
+// describeOutput returns a description of w for Config.
+func describeOutput(w io.Writer) string {
+	switch w := w.(type) {
+	case describer:
+		return w.describe()
+	case *os.File:
+		switch w {
+		case os.Stdout:
+			return "stdout"
+		case os.Stderr:
+			return "stderr"
+		}
+		return "file " + w.Name()
+	case nil:
+		return "none"
+	}
+	if w == io.Discard {
+		return "discard"
+	}
+	return fmt.Sprintf("%T", w)
+}
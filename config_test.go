@@ -0,0 +1,59 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func TestConfig(t *testing.T) {
+	l := log.NewLog()
+	c := l.Config()
+	if c.Level != log.LevelAll || c.Format != "text" || c.Output != "stderr" || c.Caller || c.Async {
+		t.Errorf("Unexpected default config %+v", c)
+	}
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := l.SetOutputFile(path); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.SetMinLevel(log.LevelWarning)
+	l.SetIncludeCaller(true)
+	l.SetColor(true)
+	l.SetAsync(10)
+	l.SetRateLimit(50)
+	l.SetPrefix("[auth] ")
+	l.SetBatching(10, time.Second)
+	var buff bytes.Buffer
+	l.SetLevelOutput(log.LevelError|log.LevelFatal, &buff)
+	c = l.With("k", 1).Config()
+	want := log.Config{
+		Level:        log.LevelAll &^ (log.LevelDebug | log.LevelInfo),
+		LevelName:    "^DEBUG|^INFO",
+		Format:       "text",
+		Output:       "file " + path + ", batched by 10",
+		LevelOutputs: map[string]string{"ERROR|FATAL": "*bytes.Buffer"},
+		TimeFormat:   time.RFC3339Nano,
+		Prefix:       "[auth] ",
+		Caller:       true,
+		Color:        true,
+		Async:        true,
+		RateLimit:    50,
+		Fields:       1,
+		StackLevels:  log.LevelFatal | log.LevelPanic,
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("got  %+v\nwant %+v", c, want)
+	}
+	b, err := json.Marshal(c)
+	if err != nil || !strings.Contains(string(b), `"level":"^DEBUG|^INFO"`) {
+		t.Errorf("got %s, %v", b, err)
+	}
+}
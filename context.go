@@ -0,0 +1,95 @@
+package log
+
+import "context"
+
+// contextFieldsKey is the context key for the fields added by WithContextField.
+type contextFieldsKey struct{}
+
+// contextKey is a context key registered with AddContextKey.
+type contextKey struct {
+	field string
+	key   interface{}
+}
+
+// WithContextField returns a copy of ctx carrying the field key=value, which the XXXCtx logging methods
+// write with every entry logged with the returned context or contexts derived from it.
+func WithContextField(ctx context.Context, key string, value interface{}) context.Context {
+	parent, _ := ctx.Value(contextFieldsKey{}).(map[string]interface{})
+	return context.WithValue(ctx, contextFieldsKey{}, withField(parent, key, value))
+}
+
+// AddContextKey makes the XXXCtx logging methods write the value stored in the context under key,
+// if there is one, as the field named field. It lets entries include context values set by other
+// packages, such as a request ID set by middleware.
+func (l *Log) AddContextKey(field string, key interface{}) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.contextKeys = append(l.contextKeys[:len(l.contextKeys):len(l.contextKeys)], contextKey{field, key})
+}
+
+// withContext returns a Log that writes the fields carried by ctx, or l if there are none. If ctx
+// is done, the returned Log also writes the context's error as the field ctx_err; the entry is still
+// written.
+func (l *Log) withContext(ctx context.Context) *Log {
+	fields, _ := ctx.Value(contextFieldsKey{}).(map[string]interface{})
+	l.mu.Lock()
+	keys := l.contextKeys
+	l.mu.Unlock()
+	for _, k := range keys {
+		if v := ctx.Value(k.key); v != nil {
+			fields = withField(fields, k.field, v)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		fields = withField(fields, "ctx_err", err)
+	}
+	if len(fields) == 0 {
+		return l
+	}
+	return l.WithFields(fields)
+}
+
+func (l *Log) DebugCtx(ctx context.Context, format string, args ...interface{}) error {
+	if !l.Enabled(LevelDebug) {
+		return nil
+	}
+	return l.withContext(ctx).Debug(format, args...)
+}
+
+func (l *Log) InfoCtx(ctx context.Context, format string, args ...interface{}) error {
+	if !l.Enabled(LevelInfo) {
+		return nil
+	}
+	return l.withContext(ctx).Info(format, args...)
+}
+
+func (l *Log) WarningCtx(ctx context.Context, format string, args ...interface{}) error {
+	if !l.Enabled(LevelWarning) {
+		return nil
+	}
+	return l.withContext(ctx).Warning(format, args...)
+}
+
+func (l *Log) ErrorCtx(ctx context.Context, format string, args ...interface{}) error {
+	if !l.Enabled(LevelError) {
+		return nil
+	}
+	return l.withContext(ctx).Error(format, args...)
+}
+
+func (l *Log) FatalCtx(ctx context.Context, format string, args ...interface{}) error {
+	if !l.Enabled(LevelFatal) {
+		return nil
+	}
+	return l.withContext(ctx).Fatal(format, args...)
+}
+
+func (l *Log) PanicCtx(ctx context.Context, format string, args ...interface{}) error {
+	if !l.Enabled(LevelPanic) {
+		return nil
+	}
+	return l.withContext(ctx).Panic(format, args...)
+}
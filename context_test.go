@@ -0,0 +1,36 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/Syncbak-Git/log"
+)
+
+type requestIDKey struct{}
+
+func TestContextFields(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	l.AddContextKey("request_id", requestIDKey{})
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "abc")
+	ctx = log.WithContextField(ctx, "user_id", 42)
+	l.InfoCtx(ctx, "Hello %s", "world")
+	l.InfoCtx(context.Background(), "no fields")
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	l.ErrorCtx(cancelled, "cancelled")
+	l.Info("plain")
+
+	want := "ts\tINFO\tHello world\trequest_id=abc user_id=42\n" +
+		"ts\tINFO\tno fields\n" +
+		"ts\tERROR\tcancelled\tctx_err=context canceled request_id=abc user_id=42\n" +
+		"ts\tINFO\tplain\n"
+	if got := buff.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,42 @@
+package log
+
+import (
+	"math/bits"
+	"sync/atomic"
+)
+
+// levelCounts holds the number of entries written at each Level bit, indexed by bit position.
+type levelCounts [64]uint64
+
+// add counts an entry at level, which should be a single Level bit.
+func (c *levelCounts) add(level Level) {
+	if level != LevelNone {
+		atomic.AddUint64(&c[bits.TrailingZeros64(uint64(level))], 1)
+	}
+}
+
+// Counts returns a snapshot of the number of entries written at each level since l was created or
+// ResetCounts was last called, keyed by level name, eg. "ERROR". Every defined level is included,
+// even if no entries have been written at it, and all custom entries are counted as "CUSTOM". Only
+// entries that pass the level filter, any Sampler and any rate limit are counted, including those later dropped by
+// an asynchronous log. Logs derived from l by WithFields share its counts; a Clone has its own.
+func (l *Log) Counts() map[string]uint64 {
+	if l == nil {
+		return nil
+	}
+	counts := make(map[string]uint64, len(levelNames))
+	for _, n := range levelNames {
+		counts[n.name] = atomic.LoadUint64(&l.counts[bits.TrailingZeros64(uint64(n.level))])
+	}
+	return counts
+}
+
+// ResetCounts sets the counts returned by Counts to zero.
+func (l *Log) ResetCounts() {
+	if l == nil {
+		return
+	}
+	for i := range l.counts {
+		atomic.StoreUint64(&l.counts[i], 0)
+	}
+}
@@ -0,0 +1,40 @@
+package log_test
+
+import (
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func TestCounts(t *testing.T) {
+	l := log.NewLog()
+	l.SetOutput(io.Discard)
+	l.SetLogLevel(log.LevelAll ^ log.LevelDebug)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Debug("suppressed")
+			l.Info("info")
+			l.WithFields(map[string]interface{}{"a": 1}).Error("error")
+			l.Custom("TEST", "custom")
+		}()
+	}
+	wg.Wait()
+	counts := l.Counts()
+	want := map[string]uint64{"DEBUG": 0, "INFO": 10, "ERROR": 10, "CUSTOM": 10, "TRACE": 0, "PANIC": 0}
+	for name, n := range want {
+		if counts[name] != n {
+			t.Errorf("Counts()[%q] = %d, want %d", name, counts[name], n)
+		}
+	}
+	l.ResetCounts()
+	for name, n := range l.Counts() {
+		if n != 0 {
+			t.Errorf("Counts()[%q] = %d after ResetCounts", name, n)
+		}
+	}
+}
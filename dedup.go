@@ -0,0 +1,131 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// dedup collapses consecutive identical entries. See SetDedup.
+type dedup struct {
+	window time.Duration
+
+	mu       sync.Mutex
+	from     *Log // the Log that wrote the last entry, or nil if there is none to compare with
+	level    Level
+	name     string
+	msg      string
+	start    time.Time // when the last entry was written
+	repeated int       // the number of times it has been suppressed since
+	gen      int       // identifies the current expiry timer
+}
+
+// repeatSummary is the summary entry for a suppressed run of identical entries.
+type repeatSummary struct {
+	from     *Log
+	level    Level
+	name     string
+	repeated int
+}
+
+func (s *repeatSummary) write() error {
+	if s == nil {
+		return nil
+	}
+	return s.from.emit(s.level, s.name, fmt.Sprintf("last message repeated %d times", s.repeated), false)
+}
+
+// SetDedup collapses consecutive identical entries, like syslog: when an entry has the same level
+// and message as the previous one (ignoring the timestamp and fields), and is written within window
+// of it, it is suppressed. Once a different entry is written, or window has elapsed since the first
+// of the identical entries, a "last message repeated N times" entry is written at the same level in
+// place of the suppressed ones. Close writes any pending summary. Logs derived from l by WithFields
+// share its state, so their entries are compared with l's, while a Clone has its own. A window of zero or less turns
+// deduplication off, after writing any pending summary.
+func (l *Log) SetDedup(window time.Duration) {
+	if l == nil {
+		return
+	}
+	var d *dedup
+	if window > 0 {
+		d = &dedup{window: window}
+	}
+	l.mu.Lock()
+	old := l.dedup
+	l.dedup = d
+	l.mu.Unlock()
+	if old != nil {
+		old.flush()
+	}
+}
+
+// flushDedup writes any pending summary of repeated entries.
+func (l *Log) flushDedup() error {
+	l.mu.Lock()
+	d := l.dedup
+	l.mu.Unlock()
+	if d == nil {
+		return nil
+	}
+	return d.flush()
+}
+
+// dedupe reports whether an entry should be written, writing the summary of the previous entry's
+// repeats first if it is different, and returns the error from writing the summary. No locks may be
+// held.
+func (l *Log) dedupe(level Level, name string, msg string) (bool, error) {
+	l.mu.Lock()
+	d, clock := l.dedup, l.clock
+	l.mu.Unlock()
+	if d == nil {
+		return true, nil
+	}
+	now := clock()
+	d.mu.Lock()
+	if d.from != nil && level == d.level && name == d.name && msg == d.msg && now.Sub(d.start) < d.window {
+		if d.repeated++; d.repeated == 1 {
+			gen := d.gen
+			time.AfterFunc(d.window-now.Sub(d.start), func() { d.expire(gen) })
+		}
+		d.mu.Unlock()
+		return false, nil
+	}
+	s := d.take()
+	d.from, d.level, d.name, d.msg, d.start = l, level, name, msg, now
+	d.mu.Unlock()
+	return true, s.write()
+}
+
+// take returns the summary of the suppressed entries, or nil if there are none, and resets the count.
+// d.mu must be held.
+func (d *dedup) take() *repeatSummary {
+	if d.repeated == 0 {
+		return nil
+	}
+	s := &repeatSummary{from: d.from, level: d.level, name: d.name, repeated: d.repeated}
+	d.repeated = 0
+	d.gen++ // the pending timer no longer applies
+	return s
+}
+
+// flush writes any pending summary, and forgets the last entry.
+func (d *dedup) flush() error {
+	d.mu.Lock()
+	s := d.take()
+	d.from = nil
+	d.mu.Unlock()
+	return s.write()
+}
+
+// expire flushes the summary when the window of the entry that started timer gen has elapsed.
+func (d *dedup) expire(gen int) {
+	d.mu.Lock()
+	if d.gen != gen {
+		d.mu.Unlock()
+		return
+	}
+	s := d.take()
+	d.from = nil
+	d.mu.Unlock()
+	s.write()
+}
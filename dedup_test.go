@@ -0,0 +1,64 @@
+package log_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func TestSetDedup(t *testing.T) {
+	var buff syncBuffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	l.SetDedup(time.Hour)
+	for i := 0; i < 5; i++ {
+		l.Error("connection refused")
+	}
+	l.Info("connected")
+	l.Info("connected")
+	l.Error("connection refused")
+	l.Info("once")
+	l.Warning("closing")
+	l.Warning("closing")
+	l.Warning("closing")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := "ts\tERROR\tconnection refused\n" +
+		"ts\tERROR\tlast message repeated 4 times\n" +
+		"ts\tINFO\tconnected\n" +
+		"ts\tINFO\tlast message repeated 1 times\n" +
+		"ts\tERROR\tconnection refused\n" +
+		"ts\tINFO\tonce\n" +
+		"ts\tWARNING\tclosing\n" +
+		"ts\tWARNING\tlast message repeated 2 times\n"
+	if got := buff.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSetDedupWindow(t *testing.T) {
+	var buff syncBuffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	l.SetDedup(20 * time.Millisecond)
+	l.Info("tick")
+	l.Info("tick")
+	l.Info("tick")
+	want := "ts\tINFO\ttick\n" +
+		"ts\tINFO\tlast message repeated 2 times\n"
+	deadline := time.Now().Add(5 * time.Second)
+	for buff.String() != want && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := buff.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	l.Info("tick")
+	if got := buff.String(); got != want+"ts\tINFO\ttick\n" {
+		t.Errorf("Expected the entry to be written after the window, got %q", got)
+	}
+}
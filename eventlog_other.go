@@ -0,0 +1,9 @@
+//go:build !windows
+
+package log
+
+import "io"
+
+func openEventLog(source string) (io.WriteCloser, error) {
+	return nil, ErrEventLogUnsupported
+}
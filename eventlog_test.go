@@ -0,0 +1,31 @@
+package log_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func TestSetOutputEventLog(t *testing.T) {
+	l := log.NewLog()
+	err := l.SetOutputEventLog("github.com/Syncbak-Git/log")
+	if runtime.GOOS != "windows" {
+		if err != log.ErrEventLogUnsupported {
+			t.Errorf("Expected ErrEventLogUnsupported, got %v", err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("Could not open event log: %s", err)
+	}
+	if err := l.Info("Hello %s", "event log"); err != nil {
+		t.Errorf("Could not write INFO entry: %s", err)
+	}
+	if err := l.Error("Hello %s", "event log"); err != nil {
+		t.Errorf("Could not write ERROR entry: %s", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("Could not close event log: %s", err)
+	}
+}
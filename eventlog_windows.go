@@ -0,0 +1,83 @@
+//go:build windows
+
+package log
+
+import (
+	"io"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32                  = syscall.NewLazyDLL("advapi32.dll")
+	procRegisterEventSourceW  = advapi32.NewProc("RegisterEventSourceW")
+	procDeregisterEventSource = advapi32.NewProc("DeregisterEventSource")
+	procReportEventW          = advapi32.NewProc("ReportEventW")
+)
+
+// Event types accepted by ReportEventW.
+const (
+	eventlogErrorType       = 0x0001
+	eventlogWarningType     = 0x0002
+	eventlogInformationType = 0x0004
+)
+
+// eventLog writes entries to the Windows Event Log.
+type eventLog struct {
+	handle uintptr
+}
+
+func openEventLog(source string) (io.WriteCloser, error) {
+	s, err := syscall.UTF16PtrFromString(source)
+	if err != nil {
+		return nil, err
+	}
+	h, _, err := procRegisterEventSourceW.Call(0, uintptr(unsafe.Pointer(s)))
+	if h == 0 {
+		return nil, err
+	}
+	return &eventLog{handle: h}, nil
+}
+
+func (e *eventLog) Write(p []byte) (int, error) {
+	if err := e.report(eventlogInformationType, string(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (e *eventLog) writeLevel(level Level, p []byte) error {
+	switch level {
+	case LevelError, LevelFatal, LevelPanic:
+		return e.report(eventlogErrorType, string(p))
+	case LevelWarning:
+		return e.report(eventlogWarningType, string(p))
+	}
+	return e.report(eventlogInformationType, string(p))
+}
+
+func (e *eventLog) report(eventType uint16, entry string) error {
+	// UTF16PtrFromString rejects embedded NULs, which the Event Log can't store anyway
+	m, err := syscall.UTF16PtrFromString(strings.ReplaceAll(strings.TrimSuffix(entry, "\n"), "\x00", ""))
+	if err != nil {
+		return err
+	}
+	r, _, err := procReportEventW.Call(e.handle, uintptr(eventType), 0, 1, 0, 1, 0, uintptr(unsafe.Pointer(&m)), 0)
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+func (e *eventLog) Close() error {
+	r, _, err := procDeregisterEventSource.Call(e.handle)
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+func (e *eventLog) describe() string {
+	return "windows event log"
+}
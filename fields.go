@@ -0,0 +1,194 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithFields returns a new Log that appends fields to every entry as key=value pairs, after the message.
+// Keys are written in sorted order and values are formatted with %v. The new Log shares l's output
+// and starts with a copy of l's settings and fields, which it can then change independently of l. If
+// l already has fields, they are merged with fields, and fields takes precedence for duplicate keys.
+func (l *Log) WithFields(fields map[string]interface{}) *Log {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	c := *l
+	l.mu.Unlock()
+	c.fields = make(map[string]interface{}, len(l.fields)+len(l.pairs)+len(fields))
+	l.mergeFields(c.fields)
+	for k, v := range fields {
+		c.fields[k] = v
+	}
+	c.pairs = nil
+	return &c
+}
+
+// With is like WithFields, but takes the fields as alternating keys and values, eg.
+// With("request_id", id, "attempt", 2). The pairs are kept in a slice rather than a map, and are only
+// merged with the other fields as each entry is formatted, so With allocates less than WithFields. Each
+// key must be a string. A non-string key, and its value, or a final key without a value, are reported
+// in a "log_error" field instead of being written.
+func (l *Log) With(keysAndValues ...interface{}) *Log {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	c := *l
+	l.mu.Unlock()
+	c.pairs = make([]field, len(l.pairs), len(l.pairs)+(len(keysAndValues)+1)/2)
+	copy(c.pairs, l.pairs)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		k, ok := keysAndValues[i].(string)
+		switch {
+		case !ok:
+			c.pairs = append(c.pairs, field{"log_error", fmt.Sprintf("With: non-string key %v (%T)", keysAndValues[i], keysAndValues[i])})
+		case i+1 == len(keysAndValues):
+			c.pairs = append(c.pairs, field{"log_error", fmt.Sprintf("With: missing value for key %q", k)})
+		default:
+			c.pairs = append(c.pairs, field{k, keysAndValues[i+1]})
+		}
+	}
+	return &c
+}
+
+// field is a key and value added by With.
+type field struct {
+	key   string
+	value interface{}
+}
+
+// mergeFields adds l's fields, and then the pairs added by With, to m.
+func (l *Log) mergeFields(m map[string]interface{}) {
+	for k, v := range l.fields {
+		m[k] = v
+	}
+	for _, f := range l.pairs {
+		m[f.key] = f.value
+	}
+}
+
+// fieldsPool holds the maps that the fields of entries from a Log with pairs added by With are merged
+// into for a built-in Formatter, which doesn't keep the map, so that With doesn't cost a map per entry.
+var fieldsPool = sync.Pool{New: func() interface{} { return make(map[string]interface{}) }}
+
+// releaseFields empties m and returns it to fieldsPool.
+func releaseFields(m map[string]interface{}) {
+	for k := range m {
+		delete(m, k)
+	}
+	fieldsPool.Put(m)
+}
+
+// WithDuration is like WithFields, but adds a single field with d as its value, written as d.String(),
+// eg. "1.5s", in every format rather than as the integer nanoseconds a JSON encoding would give.
+func (l *Log) WithDuration(key string, d time.Duration) *Log {
+	return l.WithFields(map[string]interface{}{key: d.String()})
+}
+
+// WithError is like WithFields, but adds an "error" field with err.Error() as its value. If err wraps
+// other errors, as returned by errors.Unwrap, it also adds an "error_causes" field listing the messages
+// of the wrapped errors, outermost first, and an "error_type" field with the type of the innermost one,
+// eg. "*fs.PathError". The causes are written as a list by the JSON format and as "caused by: a; b" by
+// the others. If err is nil no field is added, so the result of a call that may fail can be attached
+// unconditionally.
+func (l *Log) WithError(err error) *Log {
+	if err == nil {
+		return l.WithFields(nil)
+	}
+	fields := map[string]interface{}{"error": err.Error()}
+	var causes errorCauses
+	cause := err
+	for next := errors.Unwrap(cause); next != nil; next = errors.Unwrap(cause) {
+		causes = append(causes, next.Error())
+		cause = next
+	}
+	if causes != nil {
+		fields["error_causes"] = causes
+		fields["error_type"] = fmt.Sprintf("%T", cause)
+	}
+	return l.WithFields(fields)
+}
+
+// errorCauses holds the messages of the errors wrapped by an error. See WithError.
+type errorCauses []string
+
+func (c errorCauses) String() string {
+	return "caused by: " + strings.Join(c, "; ")
+}
+
+// DebugKV writes a DEBUG entry with msg as its message and keysAndValues as fields of that entry only,
+// eg. l.InfoKV("request done", "status", 200, "bytes", n). The fields are given as for With and are
+// written in the same way, merged with l's own fields, but l itself is unchanged. msg is written as
+// is, not as a format.
+func (l *Log) DebugKV(msg string, keysAndValues ...interface{}) error {
+	return l.writeKV(LevelDebug, "DEBUG", msg, keysAndValues)
+}
+
+// InfoKV writes an INFO entry with one-off fields. See DebugKV.
+func (l *Log) InfoKV(msg string, keysAndValues ...interface{}) error {
+	return l.writeKV(LevelInfo, "INFO", msg, keysAndValues)
+}
+
+// WarningKV writes a WARNING entry with one-off fields. See DebugKV.
+func (l *Log) WarningKV(msg string, keysAndValues ...interface{}) error {
+	return l.writeKV(LevelWarning, "WARNING", msg, keysAndValues)
+}
+
+// ErrorKV writes an ERROR entry with one-off fields. See DebugKV.
+func (l *Log) ErrorKV(msg string, keysAndValues ...interface{}) error {
+	return l.writeKV(LevelError, "ERROR", msg, keysAndValues)
+}
+
+// writeKV implements the KV methods.
+func (l *Log) writeKV(level Level, name string, msg string, keysAndValues []interface{}) error {
+	if !l.Enabled(level) || !l.sample(level, msg) {
+		return nil
+	}
+	return l.With(keysAndValues...).writeMessage(level, name, msg, false)
+}
+
+// setField sets key to value in fields if owned is set, and otherwise returns a copy of fields with key
+// set to value, so that fields belonging to the Log are never modified.
+func setField(fields map[string]interface{}, owned bool, key string, value interface{}) map[string]interface{} {
+	if owned {
+		fields[key] = value
+		return fields
+	}
+	return withField(fields, key, value)
+}
+
+// withField returns a copy of fields with key set to value.
+func withField(fields map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(fields)+1)
+	for k, v := range fields {
+		c[k] = v
+	}
+	c[key] = value
+	return c
+}
+
+// formatFields returns fields as space-separated key=value pairs in key order.
+func formatFields(fields map[string]interface{}) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%s=%v", k, fields[k])
+	}
+	return b.String()
+}
@@ -0,0 +1,168 @@
+package log_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func TestWithFields(t *testing.T) {
+	var buff bytes.Buffer
+	parent := log.NewLog()
+	parent.SetOutput(&buff)
+	parent.SetTimestamp(func() string { return "ts" })
+	child := parent.WithFields(map[string]interface{}{"request_id": "abc", "user_id": 42})
+	grandchild := child.WithFields(map[string]interface{}{"user_id": 7, "attempt": 2})
+	child.SetLogLevel(log.LevelNone)
+
+	parent.Info("parent")
+	child.Info("child") // suppressed by the child's level only
+	grandchild.Info("grandchild")
+	want := "ts\tINFO\tparent\n" +
+		"ts\tINFO\tgrandchild\tattempt=2 request_id=abc user_id=7\n"
+	if got := buff.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	buff.Reset()
+	child.SetLogLevel(log.LevelAll)
+	child.Info("child")
+	if want := "ts\tINFO\tchild\trequest_id=abc user_id=42\n"; buff.String() != want {
+		t.Errorf("got %q, want %q", buff.String(), want)
+	}
+}
+
+func TestWith(t *testing.T) {
+	var buff bytes.Buffer
+	parent := log.NewLog()
+	parent.SetOutput(&buff)
+	parent.SetTimestamp(func() string { return "ts" })
+	child := parent.WithFields(map[string]interface{}{"request_id": "abc"}).With("user_id", 42, "attempt", 2)
+	child.Info("child")
+	parent.With("user_id", 7, 42, "value").Info("bad key")
+	parent.With("user_id").Info("odd")
+	want := "ts\tINFO\tchild\tattempt=2 request_id=abc user_id=42\n" +
+		"ts\tINFO\tbad key\tlog_error=With: non-string key 42 (int) user_id=7\n" +
+		"ts\tINFO\todd\tlog_error=With: missing value for key \"user_id\"\n"
+	if got := buff.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWithPrecedence(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	l.SetIncludeCaller(true)
+	a := l.With("k", 1, "j", 1)
+	b := a.WithFields(map[string]interface{}{"k": 2}).With("j", 2)
+	a.With("k", 3).Info("a")
+	b.Info("b")
+	a.Info("a again")
+	got := buff.String()
+	for _, want := range []string{"\ta\tcaller=", " j=1 k=3\n", "\tb\tcaller=", " j=2 k=2\n", "\ta again\tcaller=", " j=1 k=1\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Missing %q:\n%s", want, got)
+		}
+	}
+	if n := b.Config().Fields; n != 2 {
+		t.Errorf("Config().Fields = %d, want 2", n)
+	}
+}
+
+func TestKV(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	l = l.With("service", "api")
+	l.InfoKV("request done", "status", 200, "path", "/a%b")
+	l.ErrorKV("failed")
+	l.Info("plain")
+	want := "ts\tINFO\trequest done\tpath=/a%b service=api status=200\n" +
+		"ts\tERROR\tfailed\tservice=api\n" +
+		"ts\tINFO\tplain\tservice=api\n"
+	if got := buff.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	buff.Reset()
+	l.SetFormat(&log.JSONFormatter{})
+	l.SetLogLevel(log.LevelAll ^ log.LevelDebug)
+	l.DebugKV("hidden", "k", 1)
+	l.WarningKV("slow", "ms", 1200)
+	if want := `{"ts":"ts","level":"WARNING","msg":"slow","ms":1200,"service":"api"}` + "\n"; buff.String() != want {
+		t.Errorf("got %q, want %q", buff.String(), want)
+	}
+}
+
+func TestWithDurationAndError(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	l.WithError(nil).Info("ok")
+	l.WithDuration("elapsed", 1500*time.Millisecond).WithError(errors.New("timeout")).Info("failed")
+	want := "ts\tINFO\tok\n" +
+		"ts\tINFO\tfailed\telapsed=1.5s error=timeout\n"
+	if got := buff.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	buff.Reset()
+	l.SetFormat(&log.JSONFormatter{})
+	l.WithDuration("elapsed", time.Second).Info("done")
+	if want := `{"ts":"ts","level":"INFO","msg":"done","elapsed":"1s"}` + "\n"; buff.String() != want {
+		t.Errorf("got %q, want %q", buff.String(), want)
+	}
+}
+
+func TestWithErrorChain(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	root := &os.PathError{Op: "open", Path: "/etc/app.conf", Err: os.ErrNotExist}
+	err := fmt.Errorf("starting: %w", fmt.Errorf("loading config: %w", root))
+	l.WithError(err).Error("failed")
+	want := "ts\tERROR\tfailed\terror=starting: loading config: open /etc/app.conf: file does not exist " +
+		"error_causes=caused by: loading config: open /etc/app.conf: file does not exist; " +
+		"open /etc/app.conf: file does not exist; file does not exist error_type=*errors.errorString\n"
+	if got := buff.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	buff.Reset()
+	l.SetFormat(&log.JSONFormatter{})
+	l.WithError(fmt.Errorf("starting: %w", root)).Error("failed")
+	want = `{"ts":"ts","level":"ERROR","msg":"failed","error":"starting: open /etc/app.conf: file does not exist",` +
+		`"error_causes":["open /etc/app.conf: file does not exist","file does not exist"],"error_type":"*errors.errorString"}` + "\n"
+	if got := buff.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func BenchmarkLog_WithFields(b *testing.B) {
+	l := log.NewLog()
+	l.SetOutput(nopWriter{})
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		l.WithFields(map[string]interface{}{"request_id": "abc", "user_id": n}).Info("Hello world")
+	}
+}
+
+func BenchmarkLog_With(b *testing.B) {
+	l := log.NewLog()
+	l.SetOutput(nopWriter{})
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		l.With("request_id", "abc", "user_id", n).Info("Hello world")
+	}
+}
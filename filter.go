@@ -0,0 +1,38 @@
+package log
+
+// filter transforms or drops an entry's message. See AddFilter.
+type filter func(level Level, msg string) (newMsg string, keep bool)
+
+// AddFilter adds f to the filters that every entry written by l passes through before it is written,
+// eg. to drop health check entries or to rewrite a noisy message. f is called with the entry's level
+// and formatted message, and returns the message to write, which is passed to the next filter, and
+// whether to keep the entry at all. Filters run in the order they were added, and the first one to
+// return keep=false drops the entry. FATAL and PANIC entries are never dropped: a filter that would
+// drop one leaves its message as it was. Filters are called without l's lock held, so they may be
+// called concurrently, and they run before the rate limit, deduplication and redactors. Logs derived
+// from l by WithFields or Clone keep its filters.
+func (l *Log) AddFilter(f func(level Level, msg string) (newMsg string, keep bool)) {
+	if l == nil || f == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// don't share the backing array with clones
+	l.filters = append(l.filters[:len(l.filters):len(l.filters)], f)
+}
+
+// filter runs the filters on msg, returning the message to write and whether to write it.
+func (l *Log) filter(level Level, msg string) (string, bool) {
+	l.mu.Lock()
+	filters := l.filters
+	l.mu.Unlock()
+	for _, f := range filters {
+		m, keep := f(level, msg)
+		if keep {
+			msg = m
+		} else if level&(LevelFatal|LevelPanic) == 0 {
+			return "", false
+		}
+	}
+	return msg, true
+}
@@ -0,0 +1,52 @@
+package log_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func TestAddFilter(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	l.AddFilter(func(level log.Level, msg string) (string, bool) {
+		return msg, !strings.Contains(msg, "healthz")
+	})
+	l.AddFilter(func(level log.Level, msg string) (string, bool) {
+		return strings.Replace(msg, "GET", "get", 1), true
+	})
+	l.AddFilter(func(level log.Level, msg string) (string, bool) {
+		return strings.ToUpper(msg), true
+	})
+	l.Info("GET /healthz")
+	l.Info("GET /users")
+	l.Error("GET /healthz failed")
+	c := l.WithFields(map[string]interface{}{"a": 1})
+	c.Info("GET /healthz")
+	want := "ts\tINFO\tGET /USERS\n"
+	if got := buff.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAddFilterCrash(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	l.SetFatalAsError(true)
+	l.AddFilter(func(level log.Level, msg string) (string, bool) {
+		return msg + "!", true
+	})
+	l.AddFilter(func(level log.Level, msg string) (string, bool) {
+		return "", false
+	})
+	l.Fatal("boom")
+	if got := buff.String(); !strings.HasPrefix(got, "ts\tFATAL\tboom!\t") {
+		t.Errorf("FATAL entry dropped or emptied: %q", got)
+	}
+}
@@ -0,0 +1,239 @@
+package log
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Formatter renders a log entry. The fields are those attached with WithFields, and may be nil. The
+// timestamp is empty if the Log's timestamps are disabled with SetTimestampEnabled, and should then be
+// left out of the entry. The returned entry should not include a line terminator; the Log adds one when
+// it writes the entry.
+// Format is called with the Log's lock held, so it doesn't have to be safe for concurrent use, but it
+// must not call the Log.
+type Formatter interface {
+	Format(timestamp, level, message string, fields map[string]interface{}) ([]byte, error)
+}
+
+// TextFormatter is the default Formatter. It writes the timestamp, level and message separated by tabs,
+// followed by a tab and the fields as space-separated key=value pairs, if there are any.
+type TextFormatter struct {
+	// Separator replaces the tab between the timestamp, level, message and fields, if it isn't empty.
+	Separator string
+	// Color wraps the level in ANSI color codes: gray for TRACE and DEBUG, green for INFO, yellow for
+	// WARNING and red for ERROR, FATAL and PANIC. It should only be set for terminal outputs.
+	Color bool
+	// EscapeNewlines writes newlines in the message and field values, such as the "stack" field added
+	// by SetStackTraceLevel, as \n, so that each entry stays on a single line for line-oriented parsers.
+	EscapeNewlines bool
+}
+
+// NewTextFormatter returns the default text Formatter.
+func NewTextFormatter() *TextFormatter {
+	return &TextFormatter{}
+}
+
+func (f *TextFormatter) Format(timestamp, level, message string, fields map[string]interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	f.formatTo(&b, timestamp, level, message, fields)
+	return b.Bytes(), nil
+}
+
+// bufferFormatter is implemented by formatters that can append an entry to the pooled buffer it is
+// written from, saving the copy of the slice returned by Format.
+type bufferFormatter interface {
+	formatTo(b *bytes.Buffer, timestamp, level, message string, fields map[string]interface{}) error
+}
+
+func (f *TextFormatter) formatTo(b *bytes.Buffer, timestamp, level, message string, fields map[string]interface{}) error {
+	sep := f.Separator
+	if sep == "" {
+		sep = "\t"
+	}
+	if f.Color {
+		level = colorLevel(level)
+	}
+	start := b.Len()
+	if timestamp != "" {
+		b.WriteString(timestamp)
+		b.WriteString(sep)
+	}
+	b.WriteString(level)
+	b.WriteString(sep)
+	b.WriteString(message)
+	if len(fields) > 0 {
+		b.WriteString(sep)
+		b.WriteString(formatFields(fields))
+	}
+	if entry := b.Bytes()[start:]; f.EscapeNewlines && bytes.IndexByte(entry, '\n') >= 0 {
+		escaped := bytes.ReplaceAll(entry, []byte("\n"), []byte(`\n`))
+		b.Truncate(start)
+		b.Write(escaped)
+	}
+	return nil
+}
+
+// JSONFormatter writes each entry as a single-line JSON object with "ts", "level" and "msg" keys followed
+// by the fields in key order. Fields named ts, level or msg are written with a "fields." prefix so they
+// don't collide with the standard keys. Field values that can't be marshaled to JSON are written as the
+// string produced by %v.
+type JSONFormatter struct{}
+
+// NewJSONFormatter returns a JSON Formatter.
+func NewJSONFormatter() *JSONFormatter {
+	return &JSONFormatter{}
+}
+
+func (f *JSONFormatter) Format(timestamp, level, message string, fields map[string]interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	if timestamp != "" {
+		b.WriteString(`"ts":`)
+		writeJSON(&b, timestamp)
+		b.WriteByte(',')
+	}
+	b.WriteString(`"level":`)
+	writeJSON(&b, level)
+	b.WriteString(`,"msg":`)
+	writeJSON(&b, message)
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteByte(',')
+		switch k {
+		case "ts", "level", "msg":
+			writeJSON(&b, "fields."+k)
+		default:
+			writeJSON(&b, k)
+		}
+		b.WriteByte(':')
+		writeJSON(&b, fields[k])
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// writeJSON writes v to b as JSON without HTML escaping, falling back to the %v string of v if it
+// can't be marshaled.
+func writeJSON(b *bytes.Buffer, v interface{}) {
+	var j bytes.Buffer
+	enc := json.NewEncoder(&j)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		j.Reset()
+		enc.Encode(fmt.Sprintf("%v", v))
+	}
+	b.Write(bytes.TrimSuffix(j.Bytes(), []byte("\n")))
+}
+
+// LogfmtFormatter writes each entry as logfmt key=value pairs: ts, level and msg followed by the fields in
+// key order. Values are formatted with %v, and are quoted (with Go escaping) if they are empty or contain
+// spaces, equals signs, quotes or control characters. Fields named ts, level or msg are written with a
+// "fields." prefix so they don't collide with the standard keys.
+type LogfmtFormatter struct{}
+
+// NewLogfmtFormatter returns a logfmt Formatter.
+func NewLogfmtFormatter() *LogfmtFormatter {
+	return &LogfmtFormatter{}
+}
+
+func (f *LogfmtFormatter) Format(timestamp, level, message string, fields map[string]interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	if timestamp != "" {
+		b.WriteString("ts=")
+		b.WriteString(logfmtValue(timestamp))
+		b.WriteByte(' ')
+	}
+	b.WriteString("level=")
+	b.WriteString(logfmtValue(level))
+	b.WriteString(" msg=")
+	b.WriteString(logfmtValue(message))
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteByte(' ')
+		switch k {
+		case "ts", "level", "msg":
+			b.WriteString("fields.")
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(logfmtValue(fmt.Sprintf("%v", fields[k])))
+	}
+	return b.Bytes(), nil
+}
+
+// logfmtValue returns s, quoted if necessary.
+func logfmtValue(s string) string {
+	if s == "" || strings.IndexFunc(s, func(r rune) bool {
+		return r == ' ' || r == '=' || r == '"' || unicode.IsControl(r)
+	}) >= 0 {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// CSVFormatter writes each entry as an RFC 4180 CSV record with four columns: the timestamp, level,
+// message and fields. Since entries have different fields, the fields are written as a single column
+// holding a JSON object, with the keys in order, as the JSONFormatter writes them; the column is empty
+// if the entry has no fields. Columns containing commas, quotes or line breaks are quoted, and quotes
+// within them are doubled, as encoding/csv does.
+type CSVFormatter struct{}
+
+// NewCSVFormatter returns a CSV Formatter.
+func NewCSVFormatter() *CSVFormatter {
+	return &CSVFormatter{}
+}
+
+func (f *CSVFormatter) Format(timestamp, level, message string, fields map[string]interface{}) ([]byte, error) {
+	var fb bytes.Buffer
+	if len(fields) > 0 {
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fb.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				fb.WriteByte(',')
+			}
+			writeJSON(&fb, k)
+			fb.WriteByte(':')
+			writeJSON(&fb, fields[k])
+		}
+		fb.WriteByte('}')
+	}
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	if err := w.Write([]string{timestamp, level, message, fb.String()}); err != nil {
+		return nil, err
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// builtinFormatter reports whether f is one of the formatters of this package, none of which keep the
+// fields they are passed.
+func builtinFormatter(f Formatter) bool {
+	switch f.(type) {
+	case *TextFormatter, *JSONFormatter, *LogfmtFormatter, *CSVFormatter, *GELFFormatter:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func TestJSONFormatter(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetFormat(log.NewJSONFormatter())
+	l.SetTimestamp(func() string { return "2006-01-02T15:04:05.999999999Z" })
+	msg := "say \"hello\"\tto <everyone>\nand \\ goodbye"
+	l.WithFields(map[string]interface{}{"user_id": 42, "msg": "shadowed"}).Error("%s", msg)
+	line := buff.String()
+	if strings.Count(line, "\n") != 1 || !strings.HasSuffix(line, "\n") {
+		t.Fatalf("Entry isn't a single line: %q", line)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &got); err != nil {
+		t.Fatalf("Invalid JSON %q: %s", line, err)
+	}
+	want := map[string]interface{}{
+		"ts":         "2006-01-02T15:04:05.999999999Z",
+		"level":      "ERROR",
+		"msg":        msg,
+		"user_id":    float64(42),
+		"fields.msg": "shadowed",
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: got %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestSetFormatDefault(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	l.SetFormat(log.NewJSONFormatter())
+	l.SetFormat(nil)
+	l.Info("Hello")
+	if want := "ts\tINFO\tHello\n"; buff.String() != want {
+		t.Errorf("got %q, want %q", buff.String(), want)
+	}
+}
+
+func TestSetFieldSeparator(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	l.WithFields(map[string]interface{}{"a": 1}).Info("tab separated")
+	if err := l.SetFieldSeparator(" "); err != nil {
+		t.Fatalf("SetFieldSeparator failed: %s", err)
+	}
+	l.WithFields(map[string]interface{}{"a": 1}).Info("space separated")
+	want := "ts\tINFO\ttab separated\ta=1\n" +
+		"ts INFO space separated a=1\n"
+	if got := buff.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	for _, sep := range []string{"", "\n", " \r\n"} {
+		if err := l.SetFieldSeparator(sep); err == nil {
+			t.Errorf("SetFieldSeparator(%q) didn't return an error", sep)
+		}
+	}
+	l.SetFormat(log.NewJSONFormatter())
+	if err := l.SetFieldSeparator(" "); err == nil {
+		t.Error("SetFieldSeparator didn't return an error for JSON")
+	}
+}
+
+func TestLogfmtFormatter(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetFormat(log.NewLogfmtFormatter())
+	l.SetTimestamp(func() string { return "2006-01-02T15:04:05Z" })
+	l.WithFields(map[string]interface{}{
+		"user":  "bob",
+		"quote": `say "hi"`,
+		"expr":  "a=b",
+		"empty": "",
+		"msg":   "shadowed",
+	}).Warning("disk almost full")
+	l.Info("bare")
+	want := `ts=2006-01-02T15:04:05Z level=WARNING msg="disk almost full" empty="" expr="a=b" fields.msg=shadowed quote="say \"hi\"" user=bob` + "\n" +
+		"ts=2006-01-02T15:04:05Z level=INFO msg=bare\n"
+	if got := buff.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCSVFormatter(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetFormat(log.NewCSVFormatter())
+	l.SetTimestamp(func() string { return "2006-01-02T15:04:05Z" })
+	l.WithFields(map[string]interface{}{"user": "bob", "n": 2}).Warning(`disk "sda", almost full`)
+	l.Info("bare")
+	want := `2006-01-02T15:04:05Z,WARNING,"disk ""sda"", almost full","{""n"":2,""user"":""bob""}"` + "\n" +
+		"2006-01-02T15:04:05Z,INFO,bare,\n"
+	if got := buff.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	records, err := csv.NewReader(&buff).ReadAll()
+	if err != nil || len(records) != 2 || records[0][2] != `disk "sda", almost full` {
+		t.Errorf("got %q (%v), want the message to round-trip", records, err)
+	}
+}
+
+func TestSetTimestampEnabled(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	l.SetTimestampEnabled(false)
+	l.Info("text")
+	l.SetFormat(log.NewJSONFormatter())
+	l.Info("json")
+	l.SetFormat(log.NewLogfmtFormatter())
+	l.Info("logfmt")
+	l.SetFormat(log.NewCSVFormatter())
+	l.Info("csv")
+	l.SetFormat(nil)
+	l.SetTimestampEnabled(true)
+	l.Info("restored")
+	want := "INFO\ttext\n" +
+		`{"level":"INFO","msg":"json"}` + "\n" +
+		"level=INFO msg=logfmt\n" +
+		",INFO,csv,\n" +
+		"ts\tINFO\trestored\n"
+	if got := buff.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,45 @@
+package log
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Framing controls how entries are delimited in the output.
+type Framing int
+
+// Supported framings. See SetFraming for usage.
+const (
+	// FramingNewline terminates each entry with a newline. It is the default.
+	FramingNewline Framing = iota
+	// FramingLengthPrefixed writes a 4-byte big-endian length before each entry, and omits the
+	// trailing newline. Use ScanFrames to split the entries back out.
+	FramingLengthPrefixed
+)
+
+// frame returns entry, which has no terminator, wrapped in a length-prefixed frame.
+func frame(entry []byte) []byte {
+	b := make([]byte, 4, 4+len(entry))
+	binary.BigEndian.PutUint32(b, uint32(len(entry)))
+	return append(b, entry...)
+}
+
+// ScanFrames is a bufio.SplitFunc that splits output written with FramingLengthPrefixed
+// into individual entries. Entries may contain newlines. Frames larger than the scanner's
+// maximum token size fail with bufio.ErrTooLong; use Scanner.Buffer to raise the limit.
+func ScanFrames(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if len(data) < 4 {
+		if atEOF && len(data) > 0 {
+			return 0, nil, errors.New("log: truncated frame header")
+		}
+		return 0, nil, nil
+	}
+	n := int(binary.BigEndian.Uint32(data))
+	if len(data) < 4+n {
+		if atEOF {
+			return 0, nil, errors.New("log: truncated frame")
+		}
+		return 0, nil, nil
+	}
+	return 4 + n, data[4 : 4+n], nil
+}
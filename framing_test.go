@@ -0,0 +1,49 @@
+package log_test
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func TestFramingLengthPrefixed(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetFraming(log.FramingLengthPrefixed)
+	l.SetTimestamp(func() string { return "ts" })
+	msgs := []string{"one line", "two\nlines", "", "three\nlines\n"}
+	for _, m := range msgs {
+		l.Info("%s", m)
+	}
+	s := bufio.NewScanner(&buff)
+	s.Split(log.ScanFrames)
+	var i int
+	for ; s.Scan(); i++ {
+		if i >= len(msgs) {
+			t.Fatalf("Too many frames: %q", s.Text())
+		}
+		if want := "ts\tINFO\t" + msgs[i]; s.Text() != want {
+			t.Errorf("Frame %d: got %q, want %q", i, s.Text(), want)
+		}
+	}
+	if err := s.Err(); err != nil {
+		t.Errorf("Scan failed: %s", err)
+	}
+	if i != len(msgs) {
+		t.Errorf("Got %d frames, want %d", i, len(msgs))
+	}
+}
+
+func TestScanFramesTruncated(t *testing.T) {
+	s := bufio.NewScanner(bytes.NewReader([]byte{0, 0, 0, 10, 'a', 'b'}))
+	s.Split(log.ScanFrames)
+	if s.Scan() {
+		t.Errorf("Scanned truncated frame: %q", s.Text())
+	}
+	if s.Err() == nil {
+		t.Error("Truncated frame didn't return an error")
+	}
+}
@@ -0,0 +1,143 @@
+package log
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// GELFFormatter writes each entry as a GELF 1.1 JSON message for Graylog, with the "version", "host",
+// "short_message", "timestamp" and "level" keys, followed by a "_level_name" key holding the level
+// name and the fields in key order, prefixed with "_". The level is the syslog severity of the entry:
+// 7 for TRACE and DEBUG, 6 for INFO and custom levels, 4 for WARNING, 3 for ERROR and 2 for FATAL and
+// PANIC. The timestamp is the entry's timestamp in seconds since the Unix epoch if it was written in
+// the default RFC3339Nano format, and the current time otherwise. Characters not allowed in GELF
+// field names are replaced with "_". Fields named "id", which GELF reserves, or "level_name" are
+// written with a "fields." prefix, eg. "_fields.id".
+type GELFFormatter struct {
+	// Host is written as the "host" of each message.
+	Host string
+}
+
+// NewGELFFormatter returns a GELF Formatter with the Host set to the name of this machine.
+func NewGELFFormatter() *GELFFormatter {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return &GELFFormatter{Host: host}
+}
+
+// gelfFieldName matches the characters not allowed in GELF field names.
+var gelfFieldName = regexp.MustCompile(`[^\w.\-]`)
+
+func (f *GELFFormatter) Format(timestamp, level, message string, fields map[string]interface{}) ([]byte, error) {
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		t = time.Now()
+	}
+	var b bytes.Buffer
+	b.WriteString(`{"version":"1.1","host":`)
+	writeJSON(&b, f.Host)
+	b.WriteString(`,"short_message":`)
+	writeJSON(&b, message)
+	fmt.Fprintf(&b, `,"timestamp":%d.%06d,"level":%d,"_level_name":`, t.Unix(), t.Nanosecond()/1e3, gelfLevel(level))
+	writeJSON(&b, level)
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		name := gelfFieldName.ReplaceAllString(k, "_")
+		switch name {
+		case "id", "level_name":
+			name = "fields." + name
+		}
+		b.WriteByte(',')
+		writeJSON(&b, "_"+name)
+		b.WriteByte(':')
+		writeJSON(&b, fields[k])
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// gelfLevel returns the syslog severity for the level name.
+func gelfLevel(level string) int {
+	switch level {
+	case "TRACE", "DEBUG":
+		return 7
+	case "WARNING":
+		return 4
+	case "ERROR":
+		return 3
+	case "FATAL", "PANIC":
+		return 2
+	}
+	return 6
+}
+
+// Limits for chunked GELF messages, as given by the GELF specification.
+const (
+	gelfChunkSize   = 1420 // the largest datagram sent
+	gelfChunkHeader = 12   // magic bytes, message ID, sequence number and count
+	gelfMaxChunks   = 128
+)
+
+// gelfOutput sends each entry as a GELF message in one or more UDP datagrams.
+type gelfOutput struct {
+	conn net.Conn
+}
+
+func dialGELF(addr string) (*gelfOutput, error) {
+	c, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &gelfOutput{conn: c}, nil
+}
+
+// Write sends p, without its trailing "\n" or "\r\n", in a single datagram if it fits, and otherwise
+// in chunks. Messages needing more than 128 chunks are rejected.
+func (g *gelfOutput) Write(p []byte) (int, error) {
+	msg := bytes.TrimRight(p, "\r\n")
+	if len(msg) <= gelfChunkSize {
+		if _, err := g.conn.Write(msg); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	data := gelfChunkSize - gelfChunkHeader
+	count := (len(msg) + data - 1) / data
+	if count > gelfMaxChunks {
+		return 0, fmt.Errorf("log: GELF message of %d bytes needs more than %d chunks", len(msg), gelfMaxChunks)
+	}
+	chunk := make([]byte, gelfChunkSize)
+	chunk[0], chunk[1] = 0x1e, 0x0f
+	if _, err := rand.Read(chunk[2:10]); err != nil {
+		return 0, err
+	}
+	chunk[11] = byte(count)
+	for i := 0; i < count; i++ {
+		chunk[10] = byte(i)
+		n := copy(chunk[gelfChunkHeader:], msg[i*data:])
+		if _, err := g.conn.Write(chunk[:gelfChunkHeader+n]); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (g *gelfOutput) Close() error {
+	return g.conn.Close()
+}
+
+func (g *gelfOutput) describe() string {
+	return "gelf " + g.conn.RemoteAddr().String()
+}
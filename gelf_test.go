@@ -0,0 +1,109 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func TestGELFFormatter(t *testing.T) {
+	f := &log.GELFFormatter{Host: "web1"}
+	b, err := f.Format("2006-01-02T15:04:05.25Z", "WARNING", "disk almost full",
+		map[string]interface{}{"id": 7, "user name": "bob", "free": 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"version":"1.1","host":"web1","short_message":"disk almost full","timestamp":1136214245.250000,` +
+		`"level":4,"_level_name":"WARNING","_free":0.5,"_fields.id":7,"_user_name":"bob"}`
+	if string(b) != want {
+		t.Errorf("got  %s\nwant %s", b, want)
+	}
+	levels := map[string]float64{"TRACE": 7, "DEBUG": 7, "INFO": 6, "WARNING": 4, "ERROR": 3, "FATAL": 2, "PANIC": 2, "TEST": 6}
+	for name, want := range levels {
+		b, _ := f.Format("not a timestamp", name, "msg", nil)
+		var m map[string]interface{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			t.Fatalf("Invalid JSON %s: %s", b, err)
+		}
+		if m["level"] != want {
+			t.Errorf("%s: got level %v, want %v", name, m["level"], want)
+		}
+		if ts, _ := m["timestamp"].(float64); time.Since(time.Unix(int64(ts), 0)) > time.Minute {
+			t.Errorf("%s: expected the current time, got %v", name, m["timestamp"])
+		}
+	}
+}
+
+// sizedFormatter formats every entry as size bytes.
+type sizedFormatter struct {
+	size int
+}
+
+func (f sizedFormatter) Format(timestamp, level, message string, fields map[string]interface{}) ([]byte, error) {
+	return bytes.Repeat([]byte("x"), f.size), nil
+}
+
+func TestSetGELFOutputChunking(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	l := log.NewLog()
+	if err := l.SetGELFOutput(conn.LocalAddr().String()); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	read := func() []byte {
+		buf := make([]byte, 65536)
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf[:n]
+	}
+
+	l.Info("Hello")
+	var m map[string]interface{}
+	if b := read(); json.Unmarshal(b, &m) != nil || m["short_message"] != "Hello" || m["level"] != 6.0 {
+		t.Errorf("Unexpected message %q", b)
+	}
+
+	l.SetTerminator("\r\n")
+	l.Info("crlf")
+	if b := read(); json.Unmarshal(b, &m) != nil || m["short_message"] != "crlf" || bytes.HasSuffix(b, []byte("\r")) {
+		t.Errorf("Unexpected message %q", b)
+	}
+	l.SetTerminator("\n")
+
+	l.SetFormat(sizedFormatter{1420})
+	l.Info("fits")
+	if b := read(); len(b) != 1420 || b[0] != 'x' {
+		t.Errorf("Expected a single 1420 byte datagram, got %d bytes", len(b))
+	}
+
+	l.SetFormat(sizedFormatter{1421})
+	l.Info("chunked")
+	var msg []byte
+	for i := 0; i < 2; i++ {
+		b := read()
+		if !bytes.HasPrefix(b, []byte{0x1e, 0x0f}) || b[10] != byte(i) || b[11] != 2 {
+			t.Fatalf("Bad chunk header % x", b[:12])
+		}
+		msg = append(msg, b[12:]...)
+	}
+	if string(msg) != strings.Repeat("x", 1421) {
+		t.Errorf("Reassembled %d bytes, want 1421", len(msg))
+	}
+
+	l.SetFormat(sizedFormatter{128*1408 + 1})
+	if err := l.Info("too big"); err == nil {
+		t.Error("Expected an error for a message needing too many chunks")
+	}
+}
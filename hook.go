@@ -0,0 +1,46 @@
+package log
+
+// Hook is notified of the entries written at the levels it was added for. Fire is called with the
+// entry's level name, timestamp and message after the entry has been written.
+type Hook interface {
+	Fire(level, timestamp, message string) error
+}
+
+// levelHook is a Hook and the levels it was added for.
+type levelHook struct {
+	levels Level
+	h      Hook
+}
+
+// AddHook adds h to the hooks fired for entries at levels, which may combine several levels, eg.
+// AddHook(LevelError|LevelFatal|LevelPanic, h). Hooks are fired in the order they were added, after
+// the entry has been written and without holding any locks, or from the background goroutine of an
+// asynchronous log. An error returned by Fire is passed to the error handler set by SetErrorHandler
+// rather than being returned by the logging call.
+func (l *Log) AddHook(levels Level, h Hook) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// don't share the backing array with clones
+	l.hooks = append(l.hooks[:len(l.hooks):len(l.hooks)], levelHook{levels: levels, h: h})
+}
+
+// hooksFor returns the hooks to fire for entries at level. l.mu must be held.
+func (l *Log) hooksFor(level Level) []Hook {
+	var hooks []Hook
+	for _, lh := range l.hooks {
+		if lh.levels&level != 0 {
+			hooks = append(hooks, lh.h)
+		}
+	}
+	return hooks
+}
+
+// fireHooks fires the entry's hooks, passing their errors to the error handler.
+func (e *entry) fireHooks() {
+	for _, h := range e.hooks {
+		e.reportError(h.Fire(e.name, e.timestamp, e.msg))
+	}
+}
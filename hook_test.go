@@ -0,0 +1,50 @@
+package log_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Syncbak-Git/log"
+)
+
+// recordingHook records the entries it is fired for, prefixed with its name.
+type recordingHook struct {
+	name  string
+	fired *[]string
+	err   error
+}
+
+func (h recordingHook) Fire(level, timestamp, message string) error {
+	*h.fired = append(*h.fired, fmt.Sprintf("%s:%s %s %s", h.name, timestamp, level, message))
+	return h.err
+}
+
+func TestAddHook(t *testing.T) {
+	var buff bytes.Buffer
+	var fired []string
+	var handled []error
+	hookErr := errors.New("hook failed")
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	l.SetErrorHandler(func(err error) { handled = append(handled, err) })
+	l.AddHook(log.LevelError, recordingHook{name: "first", fired: &fired, err: hookErr})
+	l.AddHook(log.LevelError|log.LevelWarning, recordingHook{name: "second", fired: &fired})
+	l.Info("not fired")
+	if err := l.Error("disk %s", "full"); err != nil {
+		t.Errorf("Hook error returned by the logging call: %s", err)
+	}
+	l.Warning("low memory")
+	want := []string{"first:ts ERROR disk full", "second:ts ERROR disk full", "second:ts WARNING low memory"}
+	if fmt.Sprint(fired) != fmt.Sprint(want) {
+		t.Errorf("Fired %q, want %q", fired, want)
+	}
+	if len(handled) != 1 || handled[0] != hookErr {
+		t.Errorf("Error handler called with %v", handled)
+	}
+	if buff.Len() == 0 {
+		t.Error("Entries weren't written")
+	}
+}
@@ -0,0 +1,157 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// levelNames lists the defined single-bit levels in ascending order.
+var levelNames = []struct {
+	level Level
+	name  string
+}{
+	{LevelDebug, "DEBUG"},
+	{LevelInfo, "INFO"},
+	{LevelWarning, "WARNING"},
+	{LevelError, "ERROR"},
+	{LevelFatal, "FATAL"},
+	{LevelPanic, "PANIC"},
+	{LevelCustom, "CUSTOM"},
+	{LevelTrace, "TRACE"},
+}
+
+// definedLevels returns the OR of all defined single-bit levels.
+func definedLevels() Level {
+	var d Level
+	for _, n := range levelNames {
+		d |= n.level
+	}
+	return d
+}
+
+// String returns the name of l, eg. "DEBUG", "ALL" or "NONE". Combined levels are joined with "|" in
+// ascending bit order, eg. "DEBUG|ERROR". A level that is LevelAll with some defined levels removed is
+// written as the removed levels prefixed with "^", eg. "^DEBUG" for LevelAll ^ LevelDebug. Levels outside
+// LevelAll follow, eg. "ALL|TRACE". Bits that don't correspond to a defined level are written as
+// "Level(0x...)".
+func (l Level) String() string {
+	if l == LevelNone {
+		return "NONE"
+	}
+	var names []string
+	base := l & LevelAll
+	if base == LevelAll {
+		names = append(names, "ALL")
+		l &^= LevelAll
+	} else if reserved := LevelAll &^ definedLevels(); base&reserved == reserved {
+		for _, n := range levelNames {
+			if n.level&LevelAll != 0 && base&n.level == 0 {
+				names = append(names, "^"+n.name)
+			}
+		}
+		l &^= LevelAll
+	}
+	for _, n := range levelNames {
+		if l&n.level != 0 {
+			names = append(names, n.name)
+			l &^= n.level
+		}
+	}
+	if l != 0 {
+		names = append(names, fmt.Sprintf("Level(%#x)", uint64(l)))
+	}
+	return strings.Join(names, "|")
+}
+
+// ParseLevel returns the Level described by s, which is a list of case-insensitive level names separated
+// by "," or "|", eg. "debug,error" or "info|warning". The names are those returned by Level.String, and
+// the individual levels are ORed together. A name prefixed with "^" removes that level from LevelAll, so
+// "^debug" is equivalent to LevelAll ^ LevelDebug and "^debug|^fatal" to LevelAll ^ LevelDebug ^ LevelFatal.
+// ParseLevel accepts anything Level.String returns. An empty s is an error, so that a missing setting
+// doesn't silently turn logging off; "none" gives LevelNone.
+func ParseLevel(s string) (Level, error) {
+	if strings.TrimSpace(s) == "" {
+		return LevelNone, fmt.Errorf("log: empty level")
+	}
+	var set, cleared Level
+	var negated bool
+	for _, tok := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '|' }) {
+		tok = strings.TrimSpace(tok)
+		not := strings.HasPrefix(tok, "^")
+		l, err := parseLevelName(strings.TrimPrefix(tok, "^"))
+		if err != nil {
+			return LevelNone, err
+		}
+		if not {
+			negated = true
+			cleared |= l
+		} else {
+			set |= l
+		}
+	}
+	if negated {
+		set |= LevelAll &^ cleared
+	}
+	return set, nil
+}
+
+// MarshalText implements encoding.TextMarshaler, returning the name given by String, so that a Level
+// is written as eg. "INFO|ERROR" in JSON, YAML or TOML configuration.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing text with ParseLevel.
+func (l *Level) UnmarshalText(text []byte) error {
+	parsed, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = parsed
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, writing the Level as a JSON string holding its name, eg.
+// "DEBUG|ERROR".
+func (l Level) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It accepts a JSON string, parsed with ParseLevel, or, for
+// compatibility with Levels written as numbers, a JSON number, which is used as the Level's bits.
+func (l *Level) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return l.UnmarshalText([]byte(s))
+	}
+	var v uint64
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("log: a Level must be a JSON string or number, got %s", data)
+	}
+	*l = Level(v)
+	return nil
+}
+
+// parseLevelName returns the Level for a single, case-insensitive level name.
+func parseLevelName(name string) (Level, error) {
+	u := strings.ToUpper(name)
+	switch u {
+	case "ALL":
+		return LevelAll, nil
+	case "NONE":
+		return LevelNone, nil
+	}
+	for _, n := range levelNames {
+		if u == n.name {
+			return n.level, nil
+		}
+	}
+	if strings.HasPrefix(u, "LEVEL(0X") && strings.HasSuffix(u, ")") {
+		if v, err := strconv.ParseUint(u[len("LEVEL(0X"):len(u)-1], 16, 64); err == nil {
+			return Level(v), nil
+		}
+	}
+	return LevelNone, fmt.Errorf("log: unknown level %q", name)
+}
@@ -0,0 +1,140 @@
+package log_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func TestLevelString(t *testing.T) {
+	tests := []struct {
+		level log.Level
+		want  string
+	}{
+		{log.LevelNone, "NONE"},
+		{log.LevelAll, "ALL"},
+		{log.LevelDebug, "DEBUG"},
+		{log.LevelInfo, "INFO"},
+		{log.LevelWarning, "WARNING"},
+		{log.LevelError, "ERROR"},
+		{log.LevelFatal, "FATAL"},
+		{log.LevelPanic, "PANIC"},
+		{log.LevelCustom, "CUSTOM"},
+		{log.LevelTrace, "TRACE"},
+		{log.LevelError | log.LevelDebug, "DEBUG|ERROR"},
+		{log.LevelAll ^ log.LevelDebug, "^DEBUG"},
+		{log.LevelAll ^ log.LevelDebug ^ log.LevelFatal, "^DEBUG|^FATAL"},
+		{log.LevelAll | log.LevelTrace, "ALL|TRACE"},
+		{log.LevelAll ^ log.LevelDebug | log.LevelTrace, "^DEBUG|TRACE"},
+		{1 << 20, "Level(0x100000)"},
+		{log.LevelInfo | 1<<20, "INFO|Level(0x100000)"},
+	}
+	for _, tt := range tests {
+		if got := tt.level.String(); got != tt.want {
+			t.Errorf("Level(%#x).String() = %q, want %q", uint64(tt.level), got, tt.want)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		s    string
+		want log.Level
+	}{
+		{"none", log.LevelNone},
+		{"all", log.LevelAll},
+		{"debug", log.LevelDebug},
+		{"INFO", log.LevelInfo},
+		{"Warning", log.LevelWarning},
+		{"trace", log.LevelTrace},
+		{"all|trace", log.LevelAll | log.LevelTrace},
+		{"debug,error", log.LevelDebug | log.LevelError},
+		{"info|warning", log.LevelInfo | log.LevelWarning},
+		{" info , custom ", log.LevelInfo | log.LevelCustom},
+		{"^debug", log.LevelAll ^ log.LevelDebug},
+		{"^debug|^fatal", log.LevelAll ^ log.LevelDebug ^ log.LevelFatal},
+		{"Level(0x100000)", 1 << 20},
+	}
+	for _, tt := range tests {
+		got, err := log.ParseLevel(tt.s)
+		if err != nil {
+			t.Errorf("ParseLevel(%q) returned error: %s", tt.s, err)
+		} else if got != tt.want {
+			t.Errorf("ParseLevel(%q) = %s, want %s", tt.s, got, tt.want)
+		}
+	}
+	for _, s := range []string{"", " ", "bogus", "info,bogus", "^", "Level(0xzz)"} {
+		if _, err := log.ParseLevel(s); err == nil {
+			t.Errorf("ParseLevel(%q) didn't return an error", s)
+		}
+	}
+	_, err := log.ParseLevel("info|verbose")
+	if err == nil || !strings.Contains(err.Error(), `"verbose"`) {
+		t.Errorf("ParseLevel error doesn't name the bad token: %v", err)
+	}
+}
+
+func TestParseLevelRoundTrip(t *testing.T) {
+	levels := []log.Level{
+		log.LevelNone, log.LevelAll, log.LevelDebug | log.LevelError,
+		log.LevelAll ^ log.LevelDebug, log.LevelInfo | 1<<20,
+		log.LevelAll | log.LevelTrace, log.LevelAll ^ log.LevelDebug | log.LevelTrace,
+	}
+	for _, l := range levels {
+		got, err := log.ParseLevel(l.String())
+		if err != nil || got != l {
+			t.Errorf("ParseLevel(%q) = %s, %v", l.String(), got, err)
+		}
+	}
+}
+
+func TestLevelJSON(t *testing.T) {
+	type config struct {
+		Level log.Level `json:"level"`
+	}
+	for _, l := range []log.Level{log.LevelAll, log.LevelNone, log.LevelInfo | log.LevelError, log.LevelAll ^ log.LevelDebug} {
+		b, err := json.Marshal(config{l})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var c config
+		if err := json.Unmarshal(b, &c); err != nil || c.Level != l {
+			t.Errorf("%s: round trip through %s gave %s, %v", l, b, c.Level, err)
+		}
+	}
+	var c config
+	if err := json.Unmarshal([]byte(`{"level":"info|error"}`), &c); err != nil || c.Level != log.LevelInfo|log.LevelError {
+		t.Errorf("Got %s, %v", c.Level, err)
+	}
+	if err := json.Unmarshal([]byte(`{"level":"bogus"}`), &c); err == nil || !strings.Contains(err.Error(), `unknown level "bogus"`) {
+		t.Errorf("Expected the ParseLevel error, got %v", err)
+	}
+	if err := json.Unmarshal([]byte(`{"level":""}`), &c); err == nil {
+		t.Errorf("Expected an error for an empty level, got %s", c.Level)
+	}
+}
+
+func TestLevelJSONNumber(t *testing.T) {
+	for _, l := range []log.Level{log.LevelNone, log.LevelInfo | log.LevelError, log.LevelAll | log.LevelTrace} {
+		b, err := json.Marshal(uint64(l))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got log.Level
+		if err := json.Unmarshal(b, &got); err != nil || got != l {
+			t.Errorf("%s: unmarshaling %s gave %s, %v", l, b, got, err)
+		}
+	}
+	if b, err := json.Marshal(log.LevelDebug | log.LevelError); err != nil || string(b) != `"DEBUG|ERROR"` {
+		t.Errorf("Marshal gave %s, %v", b, err)
+	}
+	var l log.Level
+	if err := json.Unmarshal([]byte(`true`), &l); err == nil {
+		t.Error("Expected an error for a JSON bool")
+	}
+	if err := json.Unmarshal([]byte(`-1`), &l); err == nil {
+		t.Error("Expected an error for a negative number")
+	}
+}
@@ -1,26 +1,89 @@
 // Package log implements a simple log file. It is similar to the standard library log package,
 // but it introduces log levels to control which log entries are actually written.
-// Note that the various SetXXX() functions are not thread-safe and should be called before
-// writing log entries (or at least while there are no parallel routines writing log entries).
+// Writing log entries is safe for concurrent use: each entry is written to the output as a single,
+// uninterrupted line. The various SetXXX() functions should still be called before writing log
+// entries (or at least while there are no parallel routines writing log entries). The exception is the
+// level, which SetLogLevel, SetMinLevel and PushLevel can change at any time, eg. from an admin endpoint.
 // Package log is the successor to github.com/Syncbak-Git/logging.
 package log
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"regexp"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Log is used for private logs. Do not create directly, use NewLog().
+//
+// A nil *Log is a valid logger that discards everything: its logging methods write nothing and return
+// nil, without exiting or panicking for Fatal and Panic, and its setters do nothing, so that an API can
+// accept a nil *Log to disable logging.
 type Log struct {
-	output    io.Writer
-	logLevel  Level
-	timestamp func() string
+	mu           *sync.Mutex // guards output and the settings below
+	writeMu      *sync.Mutex // serializes writes to output; acquired after mu
+	output       io.Writer
+	outputFile   string        // the file opened by SetOutputFile, if that set output
+	logLevel     uint64        // a Level; accessed atomically, so that it can be changed while logging
+	timestamp    func() string // nil for the clock's time formatted with timeLayout in timeLoc
+	timeLayout   string
+	timeLoc      *time.Location
+	clock        func() time.Time
+	crashFile    string
+	fatalAsError bool
+	hasStack     bool // the "stack" field is the stack trace of a recovered panic; see logRecovered
+	framing      Framing
+	exit         func(int)
+	fields       map[string]interface{} // never modified once set; see WithFields
+	pairs        []field                // added by With after fields; never modified once set
+	formatter    Formatter
+	withCaller   bool
+	async        *asyncQueue
+	asyncDrop    bool
+	dropped      *uint64 // accessed atomically
+	contextKeys  []contextKey
+	levelOutputs []levelOutput
+	levelFiles   []io.Writer // the files opened by SetOutputFilesByLevel
+	sampler      Sampler
+	errorHandler func(error)
+	counts       *levelCounts
+	hooks        []levelHook
+	redactors    []redactor
+	filters      []filter
+	dedup        *dedup
+	compress     bool
+	maxAge       time.Duration
+	stackLevels  Level
+	sampled      *uint64 // accessed atomically
+	prefix       string
+	terminator   string
+	noTimestamp  bool
+	flushEach    bool
+	limiter      *rateLimiter
+	rateLimited  *uint64 // accessed atomically
 }
 
 var std *Log
 
+// Errors returned when setting up an output.
+var (
+	// ErrNotReopenable is returned by Reopen when the output was not set by SetOutputFile.
+	ErrNotReopenable = errors.New("log: output was not set by SetOutputFile")
+	// ErrEventLogUnsupported is returned by SetOutputEventLog on platforms other than Windows.
+	ErrEventLogUnsupported = errors.New("log: the Windows Event Log is not supported on this platform")
+	// ErrSyslogUnsupported is returned by SetSyslogOutput on platforms without syslog.
+	ErrSyslogUnsupported = errors.New("log: syslog is not supported on this platform")
+)
+
 func init() {
 	std = NewLog()
 }
@@ -41,37 +104,351 @@ const (
 	LevelNone = 0
 )
 
+// LevelTrace is for very verbose diagnostic entries, less severe than LevelDebug. It lies outside
+// LevelAll, so it is disabled by default and by SetLogLevel(LevelAll); enable it explicitly, eg. with
+// SetLogLevel(LevelAll | LevelTrace) or SetMinLevel(LevelTrace).
+const LevelTrace Level = 1 << 16
+
 // SetLogLevel controls which log entries are actually written to the global log.
 // Multiple logging levels can be combined by ORing individual
 // Level values, eg. LevelDebug|LevelError will log both DEBUG and ERROR entries. Alternatively,
 // specific log levels can be suppresed via XORing with LevelAll, eg. LevelAll ^ LevelDebug
 // will log everything except DEBUG entries.
-// The default log level is LevelAll.
+// The default log level is LevelAll, which doesn't include LevelTrace.
 func SetLogLevel(l Level) {
 	std.SetLogLevel(l)
 }
 
+// SetMinLevel is a convenience function to wrap SetLogLevel() for threshold-style filtering of the
+// global log: entries at min and all more severe levels are written, where the levels in increasing
+// severity are LevelTrace, LevelDebug, LevelInfo, LevelWarning, LevelError, LevelFatal and
+// LevelPanic. If min combines several levels, the least severe of them is used. Custom entries have
+// no place in that ordering, so LevelCustom is always enabled by SetMinLevel; use SetLogLevel(...)
+// to exclude it explicitly. SetMinLevel(LevelNone) disables all entries.
+func SetMinLevel(min Level) {
+	std.SetMinLevel(min)
+}
+
+// PushLevel sets the level of the global log and returns a function that restores the previous level.
+// See (*Log).PushLevel.
+func PushLevel(level Level) func() {
+	return std.PushLevel(level)
+}
+
+// InitFromEnv sets the level of the global log from the environment variable varName, eg. LOG_LEVEL,
+// parsed with ParseLevel, so that LOG_LEVEL=debug,error enables only DEBUG and ERROR entries. The level
+// is left unchanged if the variable is unset or empty, or if it can't be parsed, in which case the
+// error is returned.
+func InitFromEnv(varName string) error {
+	s := os.Getenv(varName)
+	if s == "" {
+		return nil
+	}
+	l, err := ParseLevel(s)
+	if err != nil {
+		return fmt.Errorf("log: %s: %w", varName, err)
+	}
+	std.SetLogLevel(l)
+	return nil
+}
+
 // SetOutput directs global log output to w. The default output is written to os.Stderr.
+// SetOutput(io.Discard) silences the log cheaply: the entries it would write are counted, but not
+// formatted, unless a hook is added for their level.
 func SetOutput(w io.Writer) {
 	std.SetOutput(w)
 }
 
-// Close calls Close on the output Writer, if it is a WriteCloser, otherwise Close is a no-op.
+// Close flushes the output, as described for Flush, and then calls Close on the output Writer, if it
+// is a WriteCloser. Outputs set by SetLevelOutput are closed too, each one once. If
+// the global log is asynchronous, any queued entries are written first and the background writer
+// is stopped, after which entries are written synchronously.
 func Close() error {
 	return std.Close()
 }
 
+// SetAsync makes the global log asynchronous. See (*Log).SetAsync.
+func SetAsync(bufferSize int) {
+	std.SetAsync(bufferSize)
+}
+
+// SetAsyncDropPolicy controls what the asynchronous global log does when its buffer is full. See
+// (*Log).SetAsyncDropPolicy.
+func SetAsyncDropPolicy(drop bool) {
+	std.SetAsyncDropPolicy(drop)
+}
+
+// Flush waits until all queued asynchronous global log entries have been written, and then flushes
+// outputs that buffer. See (*Log).Flush.
+func Flush() error {
+	return std.Flush()
+}
+
+// SetLevelOutput directs global log entries of the levels in level to w. See (*Log).SetLevelOutput.
+func SetLevelOutput(level Level, w io.Writer) {
+	std.SetLevelOutput(level, w)
+}
+
+// SetOutputFilesByLevel appends global log entries to files chosen by their level. See
+// (*Log).SetOutputFilesByLevel.
+func SetOutputFilesByLevel(spec map[Level]string) error {
+	return std.SetOutputFilesByLevel(spec)
+}
+
+// AddOutput adds w to the writers that global log entries are written to. See (*Log).AddOutput.
+func AddOutput(w io.Writer) {
+	std.AddOutput(w)
+}
+
 // SetOuputFile is a convenience function to wrap SetOutput() for writing global log entries to a file.
 func SetOutputFile(f string) error {
 	return std.SetOutputFile(f)
 }
 
+// SetOutputRotatingFile directs global log entries to the file f, which is rotated once it reaches maxBytes.
+// See (*Log).SetOutputRotatingFile.
+func SetOutputRotatingFile(f string, maxBytes int64, maxBackups int) error {
+	return std.SetOutputRotatingFile(f, maxBytes, maxBackups)
+}
+
+// SetOutputRotatingByTime directs global log entries to a new file for each interval. See
+// (*Log).SetOutputRotatingByTime.
+func SetOutputRotatingByTime(pathTemplate string, interval time.Duration) error {
+	return std.SetOutputRotatingByTime(pathTemplate, interval)
+}
+
+// Reopen closes and reopens the global log file set by SetOutputFile. See (*Log).Reopen.
+func Reopen() error {
+	return std.Reopen()
+}
+
 // SetTimestamp allows the user to replace the default RFC3339Nano timestamp string used by the global log. It
 // is intended for creating deterministic test cases, but may be generally useful.
 func SetTimestamp(f func() string) {
 	std.SetTimestamp(f)
 }
 
+// SetTimeFormat sets the layout and location of the global log's timestamps. See (*Log).SetTimeFormat.
+func SetTimeFormat(layout string, loc *time.Location) {
+	std.SetTimeFormat(layout, loc)
+}
+
+// SetClock replaces time.Now as the global log's source of the current time. See (*Log).SetClock.
+func SetClock(now func() time.Time) {
+	std.SetClock(now)
+}
+
+// SetCrashFile directs global PANIC and FATAL entries, along with a stack trace, to the file f in
+// addition to the normal output. The crash file is written and synced before exiting or panicking,
+// so the crash is recorded even if the normal output is buffered. An empty f disables the crash file.
+func SetCrashFile(f string) {
+	std.SetCrashFile(f)
+}
+
+// SetFatalAsError controls whether global FATAL and PANIC entries terminate the program. When true,
+// Fatal and Panic write their entries like Error does (keeping the FATAL and PANIC level tags) but
+// neither exit nor panic. It is intended for test harnesses that need to exercise those code paths.
+func SetFatalAsError(b bool) {
+	std.SetFatalAsError(b)
+}
+
+// SetFraming controls how global log entries are delimited in the output. The default is FramingNewline.
+func SetFraming(f Framing) {
+	std.SetFraming(f)
+}
+
+// Sync flushes the global log output and commits it to stable storage. See (*Log).Sync.
+func Sync() error {
+	return std.Sync()
+}
+
+// SetTerminator sets the string written after each global log entry, "\n" by default. See
+// (*Log).SetTerminator.
+func SetTerminator(s string) {
+	std.SetTerminator(s)
+}
+
+// SetFlushEachEntry controls whether a buffering global log output is flushed after every entry. See
+// (*Log).SetFlushEachEntry.
+func SetFlushEachEntry(b bool) {
+	std.SetFlushEachEntry(b)
+}
+
+// SetTimestampEnabled controls whether global log entries include a timestamp. See
+// (*Log).SetTimestampEnabled.
+func SetTimestampEnabled(b bool) {
+	std.SetTimestampEnabled(b)
+}
+
+// SetPrefix sets a prefix, such as "[auth] ", written at the start of the message of each global log
+// entry. See (*Log).SetPrefix.
+func SetPrefix(p string) {
+	std.SetPrefix(p)
+}
+
+// SetOutputEventLog directs global log output to the Windows Event Log under the given event source.
+// See (*Log).SetOutputEventLog.
+func SetOutputEventLog(source string) error {
+	return std.SetOutputEventLog(source)
+}
+
+// SetExitFunc replaces the function called by the global Fatal after writing its entry. The default
+// is os.Exit; tests can install a function that records the exit code instead. A nil f restores
+// os.Exit.
+func SetExitFunc(f func(int)) {
+	std.SetExitFunc(f)
+}
+
+// SetFormat controls how global log entries are rendered. The default is a TextFormatter, which writes
+// tab-separated lines; use NewJSONFormatter() for JSON. A nil f restores the default.
+func SetFormat(f Formatter) {
+	std.SetFormat(f)
+}
+
+// SetFieldSeparator replaces the tab that separates the fields of global log entries. See
+// (*Log).SetFieldSeparator.
+func SetFieldSeparator(sep string) error {
+	return std.SetFieldSeparator(sep)
+}
+
+// SetColor controls whether the levels of global log entries are colored. See (*Log).SetColor.
+func SetColor(b bool) {
+	std.SetColor(b)
+}
+
+// SetColorAuto colors the levels of global log entries if the output is a terminal. See
+// (*Log).SetColorAuto.
+func SetColorAuto() {
+	std.SetColorAuto()
+}
+
+// SetIncludeCaller controls whether global log entries include the file name and line number of the
+// logging call, as a "caller" field such as caller=main.go:42. It is off by default, because finding
+// the caller adds noticeable overhead to every entry.
+func SetIncludeCaller(b bool) {
+	std.SetIncludeCaller(b)
+}
+
+// SetSampler makes the global log suppress the entries that s rejects. See (*Log).SetSampler.
+func SetSampler(s Sampler) {
+	std.SetSampler(s)
+}
+
+// FlushSampled writes a summary entry with the number of global log entries suppressed by the Sampler.
+// See (*Log).FlushSampled.
+func FlushSampled() error {
+	return std.FlushSampled()
+}
+
+// SetErrorHandler sets a func that is called with the error whenever writing a global log entry
+// fails. See (*Log).SetErrorHandler.
+func SetErrorHandler(f func(error)) {
+	std.SetErrorHandler(f)
+}
+
+// AddHook adds h to the hooks fired for global log entries at levels. See (*Log).AddHook.
+func AddHook(levels Level, h Hook) {
+	std.AddHook(levels, h)
+}
+
+// SetRateLimit caps the global log at perSecond entries a second, dropping the rest. See
+// (*Log).SetRateLimit.
+func SetRateLimit(perSecond int) {
+	std.SetRateLimit(perSecond)
+}
+
+// FlushRateLimited writes a summary entry with the number of global log entries dropped by the rate
+// limit. See (*Log).FlushRateLimited.
+func FlushRateLimited() error {
+	return std.FlushRateLimited()
+}
+
+// SetBatching makes the global log write its entries to the output in batches. See (*Log).SetBatching.
+func SetBatching(maxEntries int, maxDelay time.Duration) {
+	std.SetBatching(maxEntries, maxDelay)
+}
+
+// SetDedup collapses consecutive identical global log entries. See (*Log).SetDedup.
+func SetDedup(window time.Duration) {
+	std.SetDedup(window)
+}
+
+// AddRedactor masks matches of pattern in global log messages with replacement. See (*Log).AddRedactor.
+func AddRedactor(pattern *regexp.Regexp, replacement string) {
+	std.AddRedactor(pattern, replacement)
+}
+
+// AddFilter adds a filter that transforms or drops global log entries. See (*Log).AddFilter.
+func AddFilter(f func(level Level, msg string) (newMsg string, keep bool)) {
+	std.AddFilter(f)
+}
+
+// SetCompressBackups controls whether rotated global log files are compressed. See
+// (*Log).SetCompressBackups.
+func SetCompressBackups(b bool) {
+	std.SetCompressBackups(b)
+}
+
+// SetStackTraceLevel sets the levels of the global log entries that include a stack trace. See
+// (*Log).SetStackTraceLevel.
+func SetStackTraceLevel(levels Level) {
+	std.SetStackTraceLevel(levels)
+}
+
+// SetMaxAge removes rotated global log files older than d. See (*Log).SetMaxAge.
+func SetMaxAge(d time.Duration) {
+	std.SetMaxAge(d)
+}
+
+// Clone returns a new private log with the global log's settings. See (*Log).Clone.
+func Clone() *Log {
+	return std.Clone()
+}
+
+// WithFields returns a new private log that writes fields with every entry. See (*Log).WithFields.
+func WithFields(fields map[string]interface{}) *Log {
+	return std.WithFields(fields)
+}
+
+// With returns a new private log that writes the alternating keys and values with every entry. See
+// (*Log).With.
+func With(keysAndValues ...interface{}) *Log {
+	return std.With(keysAndValues...)
+}
+
+// Writer returns an io.Writer that writes each line written to it as a global log entry at level.
+// See (*Log).Writer.
+func Writer(level Level) io.Writer {
+	return std.Writer(level)
+}
+
+// Enabled reports whether global log entries at level would be written. If level combines several
+// levels, Enabled reports whether any of them would be written.
+func Enabled(level Level) bool {
+	return std.Enabled(level)
+}
+
+// SetSyslogOutput directs global log output to the local syslog daemon. See (*Log).SetSyslogOutput.
+func SetSyslogOutput(tag string) error {
+	return std.SetSyslogOutput(tag)
+}
+
+// SetTCPOutput directs global log output to a TCP connection to addr. See (*Log).SetTCPOutput.
+func SetTCPOutput(addr string, dialTimeout time.Duration) error {
+	return std.SetTCPOutput(addr, dialTimeout)
+}
+
+// SetGELFOutput sends global log entries to a Graylog GELF UDP input at addr. See (*Log).SetGELFOutput.
+func SetGELFOutput(addr string) error {
+	return std.SetGELFOutput(addr)
+}
+
+// Trace writes a TRACE entry to the global log file. TRACE entries are only written if LevelTrace has
+// been enabled.
+func Trace(format string, args ...interface{}) error {
+	return std.Trace(format, args...)
+}
+
 // Debug writes a DEBUG entry to the global log file.
 func Debug(format string, args ...interface{}) error {
 	return std.Debug(format, args...)
@@ -92,6 +469,38 @@ func Error(format string, args ...interface{}) error {
 	return std.Error(format, args...)
 }
 
+// DebugKV writes a DEBUG entry with one-off fields to the global log file. See (*Log).DebugKV.
+func DebugKV(msg string, keysAndValues ...interface{}) error {
+	return std.DebugKV(msg, keysAndValues...)
+}
+
+// InfoKV writes an INFO entry with one-off fields to the global log file. See (*Log).DebugKV.
+func InfoKV(msg string, keysAndValues ...interface{}) error {
+	return std.InfoKV(msg, keysAndValues...)
+}
+
+// WarningKV writes a WARNING entry with one-off fields to the global log file. See (*Log).DebugKV.
+func WarningKV(msg string, keysAndValues ...interface{}) error {
+	return std.WarningKV(msg, keysAndValues...)
+}
+
+// ErrorKV writes an ERROR entry with one-off fields to the global log file. See (*Log).DebugKV.
+func ErrorKV(msg string, keysAndValues ...interface{}) error {
+	return std.ErrorKV(msg, keysAndValues...)
+}
+
+// ErrorIf writes an ERROR entry to the global log file if err is not nil, and returns err. See
+// (*Log).ErrorIf.
+func ErrorIf(err error, format string, args ...interface{}) error {
+	return std.ErrorIf(err, format, args...)
+}
+
+// WarnIf writes a WARNING entry to the global log file if err is not nil, and returns err. See
+// (*Log).ErrorIf.
+func WarnIf(err error, format string, args ...interface{}) error {
+	return std.WarnIf(err, format, args...)
+}
+
 // Fatal writes a FATAL entry to the global log file and then exits
 // via os.Exit(1).
 func Fatal(format string, args ...interface{}) error {
@@ -104,104 +513,1514 @@ func Panic(format string, args ...interface{}) error {
 	return std.Panic(format, args...)
 }
 
+// RecoverAndLog recovers a panic, writes it to the global log and panics again. It must be deferred
+// directly, eg. defer log.RecoverAndLog(). See (*Log).RecoverAndLog.
+func RecoverAndLog() {
+	if r := recover(); r != nil {
+		std.logRecovered(r, true)
+		panic(r)
+	}
+}
+
+// RecoverAndSwallow recovers a panic and writes it to the global log, without panicking again. It must
+// be deferred directly, eg. defer log.RecoverAndSwallow(). See (*Log).RecoverAndSwallow.
+func RecoverAndSwallow() {
+	if r := recover(); r != nil {
+		std.logRecovered(r, false)
+	}
+}
+
 // Custom writes a global log entry with a caller-supplied log level string.
 func Custom(level string, format string, args ...interface{}) error {
 	return std.Custom(level, format, args...)
 }
 
+// DebugFunc writes a DEBUG entry with the message returned by f to the global log file. f is only called
+// if DEBUG entries are enabled, so expensive messages cost nothing when they aren't written:
+//
+//	log.DebugFunc(func() string { return dump(state) })
+func DebugFunc(f func() string) error {
+	return std.DebugFunc(f)
+}
+
+// InfoFunc writes an INFO entry with the message returned by f to the global log file. f is only called
+// if INFO entries are enabled.
+func InfoFunc(f func() string) error {
+	return std.InfoFunc(f)
+}
+
+// WarningFunc writes a WARNING entry with the message returned by f to the global log file. f is only
+// called if WARNING entries are enabled.
+func WarningFunc(f func() string) error {
+	return std.WarningFunc(f)
+}
+
+// ErrorFunc writes an ERROR entry with the message returned by f to the global log file. f is only called
+// if ERROR entries are enabled.
+func ErrorFunc(f func() string) error {
+	return std.ErrorFunc(f)
+}
+
+// AddContextKey makes the global XXXCtx functions write the context value stored under key as the
+// field named field. See (*Log).AddContextKey.
+func AddContextKey(field string, key interface{}) {
+	std.AddContextKey(field, key)
+}
+
+// DebugCtx writes a DEBUG entry, with the fields carried by ctx, to the global log file. The fields are
+// those added with WithContextField and the values of the keys registered with AddContextKey.
+func DebugCtx(ctx context.Context, format string, args ...interface{}) error {
+	return std.DebugCtx(ctx, format, args...)
+}
+
+// InfoCtx writes an INFO entry, with the fields carried by ctx, to the global log file.
+func InfoCtx(ctx context.Context, format string, args ...interface{}) error {
+	return std.InfoCtx(ctx, format, args...)
+}
+
+// WarningCtx writes a WARNING entry, with the fields carried by ctx, to the global log file.
+func WarningCtx(ctx context.Context, format string, args ...interface{}) error {
+	return std.WarningCtx(ctx, format, args...)
+}
+
+// ErrorCtx writes an ERROR entry, with the fields carried by ctx, to the global log file.
+func ErrorCtx(ctx context.Context, format string, args ...interface{}) error {
+	return std.ErrorCtx(ctx, format, args...)
+}
+
+// FatalCtx writes a FATAL entry, with the fields carried by ctx, to the global log file and then exits
+// via os.Exit(1).
+func FatalCtx(ctx context.Context, format string, args ...interface{}) error {
+	return std.FatalCtx(ctx, format, args...)
+}
+
+// PanicCtx writes a PANIC entry, with the fields carried by ctx, to the global log file and then calls
+// panic() with the log entry.
+func PanicCtx(ctx context.Context, format string, args ...interface{}) error {
+	return std.PanicCtx(ctx, format, args...)
+}
+
+// Debugln writes a DEBUG entry to the global log file. The message is args separated by spaces, as
+// with fmt.Sprintln but without the newline; % characters in args are written literally.
+func Debugln(args ...interface{}) error {
+	return std.Debugln(args...)
+}
+
+// Infoln writes an INFO entry to the global log file. The message is args separated by spaces.
+func Infoln(args ...interface{}) error {
+	return std.Infoln(args...)
+}
+
+// Warningln writes a WARNING entry to the global log file. The message is args separated by spaces.
+func Warningln(args ...interface{}) error {
+	return std.Warningln(args...)
+}
+
+// Errorln writes an ERROR entry to the global log file. The message is args separated by spaces.
+func Errorln(args ...interface{}) error {
+	return std.Errorln(args...)
+}
+
+// Fatalln writes a FATAL entry to the global log file and then exits via os.Exit(1). The message is
+// args separated by spaces.
+func Fatalln(args ...interface{}) error {
+	return std.Fatalln(args...)
+}
+
+// Panicln writes a PANIC entry to the global log file and then calls panic() with the log entry. The
+// message is args separated by spaces.
+func Panicln(args ...interface{}) error {
+	return std.Panicln(args...)
+}
+
 // NewLog creates a private log with all log levels enabled and output to os.Stderr.
 func NewLog() *Log {
 	return &Log{
-		output:   os.Stderr,
-		logLevel: LevelAll,
-		timestamp: func() string {
-			return time.Now().UTC().Format(time.RFC3339Nano)
-		},
+		mu:          &sync.Mutex{},
+		writeMu:     &sync.Mutex{},
+		dropped:     new(uint64),
+		sampled:     new(uint64),
+		rateLimited: new(uint64),
+		counts:      new(levelCounts),
+		output:      os.Stderr,
+		logLevel:    uint64(LevelAll),
+		timeLayout:  time.RFC3339Nano,
+		timeLoc:     time.UTC,
+		clock:       time.Now,
+		stackLevels: LevelFatal | LevelPanic,
+		terminator:  "\n",
+		exit:        os.Exit,
+		formatter:   &TextFormatter{},
+	}
+}
+
+// NewFileLog returns a new Log that appends its entries to the file at path, creating it if needed,
+// as if SetOutputFile had been called. Call Sync to commit entries to disk, and Close to close the file.
+func NewFileLog(path string) (*Log, error) {
+	l := NewLog()
+	if err := l.SetOutputFile(path); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// MustFileLog is like NewFileLog, but panics if the file can't be opened. It is meant for initializing
+// package-level variables, where the error can't be returned:
+//
+//	var auditLog = log.MustFileLog("/var/log/app/audit.log")
+func MustFileLog(path string) *Log {
+	l, err := NewFileLog(path)
+	if err != nil {
+		panic(fmt.Sprintf("log: opening log file %s: %v", path, err))
 	}
+	return l
 }
 
 func (l *Log) SetLogLevel(ll Level) {
-	l.logLevel = ll
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	atomic.StoreUint64(&l.logLevel, uint64(ll))
+}
+
+// level returns the current level.
+func (l *Log) level() Level {
+	return Level(atomic.LoadUint64(&l.logLevel))
+}
+
+// PushLevel sets the level to level, like SetLogLevel, and returns a function that restores the level
+// in effect before the call, to be deferred:
+//
+//	defer l.PushLevel(LevelAll | LevelTrace)()
+//
+// Nested calls are restored correctly as long as their restore functions are called in reverse order,
+// as deferred calls are. A SetLogLevel call made in between is undone by the restore.
+func (l *Log) PushLevel(level Level) func() {
+	if l == nil {
+		return func() {}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	prev := atomic.SwapUint64(&l.logLevel, uint64(level))
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		atomic.StoreUint64(&l.logLevel, prev)
+	}
+}
+
+func (l *Log) SetMinLevel(min Level) {
+	if min&LevelTrace != 0 {
+		l.SetLogLevel(LevelAll | LevelTrace)
+		return
+	}
+	min &= LevelAll
+	lowest := min & -min // the least severe level in min
+	l.SetLogLevel(LevelAll &^ (lowest - 1))
 }
 
 func (l *Log) SetOutput(w io.Writer) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	l.output = w
+	l.outputFile = ""
+	l.configureOutputs()
 }
 
-func (l *Log) Close() error {
-	if o, ok := l.output.(io.WriteCloser); ok {
-		return o.Close()
+// SetLevelOutput directs entries of the levels in level, which may combine several levels, to w instead
+// of the output set by SetOutput, eg. SetLevelOutput(LevelError|LevelFatal|LevelPanic, os.Stderr). Levels
+// that haven't been given an output of their own are written to the default output. A later call
+// overrides an earlier one for the levels they share, and a nil w returns the levels to the default
+// output. Close closes each distinct level output as well as the default output.
+func (l *Log) SetLevelOutput(level Level, w io.Writer) {
+	if l == nil {
+		return
 	}
-	return nil
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.levelOutputs = setLevelOutput(l.levelOutputs, level, w)
+	l.configureOutputs()
 }
 
-func (l *Log) SetOutputFile(f string) error {
-	w, err := os.OpenFile(f, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if err != nil {
-		return err
+// SetOutputFilesByLevel appends entries to files chosen by their level. Each key of spec is a Level,
+// which may combine several levels, and its value the path of the file that entries of those levels
+// are written to, so that an entry is written to every file whose levels include it, eg.
+//
+//	l.SetOutputFilesByLevel(map[Level]string{
+//		LevelAll:                           "app.log",
+//		LevelError | LevelFatal | LevelPanic: "error.log",
+//	})
+//
+// writes every entry to app.log and errors to error.log as well. A path may appear more than once, and
+// is opened once. The files replace the outputs set by earlier SetLevelOutput calls for the levels in
+// spec, and entries of levels that aren't in spec are still written to the default output. If a file
+// can't be opened, the files already opened are closed, l is left unchanged, and the error is returned.
+// Files opened by an earlier call that are no longer written to are closed, and Close closes the rest.
+func (l *Log) SetOutputFilesByLevel(spec map[Level]string) error {
+	if l == nil {
+		return nil
 	}
-	l.SetOutput(w)
-	return nil
+	files := make(map[string]*os.File)
+	for _, path := range spec {
+		if files[path] != nil {
+			continue
+		}
+		f, err := openFile(path)
+		if err != nil {
+			for _, f := range files {
+				f.Close()
+			}
+			return err
+		}
+		files[path] = f
+	}
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	// the levels of each distinct set of files, keyed by the indexes of the files in paths
+	routes := make(map[string]Level)
+	var order []string
+	for bit := Level(1); bit != 0; bit <<= 1 {
+		var key []byte
+		for i, path := range paths {
+			for mask, p := range spec {
+				if p == path && mask&bit != 0 {
+					key = append(key, byte(i))
+					break
+				}
+			}
+		}
+		if key == nil {
+			continue
+		}
+		if _, ok := routes[string(key)]; !ok {
+			order = append(order, string(key))
+		}
+		routes[string(key)] |= bit
+	}
+	l.mu.Lock()
+	for _, key := range order {
+		var w io.Writer = files[paths[key[0]]]
+		if len(key) > 1 {
+			m := make(multiOutput, len(key))
+			for i, idx := range []byte(key) {
+				m[i] = files[paths[idx]]
+			}
+			w = m
+		}
+		l.levelOutputs = setLevelOutput(l.levelOutputs, routes[key], w)
+	}
+	levelFiles := make([]io.Writer, 0, len(files))
+	for _, path := range paths {
+		levelFiles = append(levelFiles, files[path])
+	}
+	live := flattenWriters(l.outputs())
+	var unused []io.Writer
+	for _, f := range l.levelFiles {
+		if containsWriter(live, f) {
+			levelFiles = append(levelFiles, f)
+		} else {
+			unused = append(unused, f)
+		}
+	}
+	l.levelFiles = levelFiles
+	q := l.async
+	l.mu.Unlock()
+	if q != nil {
+		q.flush() // queued entries may still be written to the unused files
+	}
+	return closeWriters(unused...)
 }
 
-func (l *Log) SetTimestamp(f func() string) {
-	l.timestamp = f
+// AddOutput adds w to the writers that entries are written to, so that each entry is written to the
+// current output and to w. Unlike io.MultiWriter, a failing writer doesn't stop the entry being written
+// to the others; the logging call returns the errors of all the failed writers, joined with errors.Join.
+// Close closes every writer that is an io.WriteCloser. SetOutput replaces all the writers.
+func (l *Log) AddOutput(w io.Writer) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if m, ok := l.output.(multiOutput); ok {
+		l.output = append(m[:len(m):len(m)], w) // don't share the backing array with clones
+	} else {
+		l.output = multiOutput{l.output, w}
+	}
+	l.outputFile = ""
+	l.configureOutputs()
 }
 
-func (l *Log) Debug(format string, args ...interface{}) error {
-	if l.logLevel&LevelDebug == 0 {
+func (l *Log) Close() error {
+	if l == nil {
 		return nil
 	}
-	return l.writeEntry("DEBUG", format, args...)
+	l.flushDedup()
+	l.mu.Lock()
+	q := l.async
+	l.async = nil
+	l.mu.Unlock()
+	if q != nil {
+		q.close()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ferr := l.flushOutputs()
+	if err := closeWriters(l.outputs()...); err != nil {
+		return err
+	}
+	return ferr
 }
 
-func (l *Log) Info(format string, args ...interface{}) error {
-	if l.logLevel&LevelInfo == 0 {
+// SetAsync makes the log asynchronous: entries are formatted by the logging call, but are then queued
+// in a buffer of bufferSize entries and written to the output by a background goroutine, so the caller
+// doesn't wait for the output. Errors writing queued entries are not reported to the caller. When the
+// buffer is full, the logging call waits for space, unless SetAsyncDropPolicy(true) has been called.
+// FATAL and PANIC entries are always written synchronously, after the queued entries. Use Flush to wait
+// for queued entries to be written, and Close to write them and stop the background goroutine. A
+// bufferSize of zero or less makes the log synchronous again, after writing any queued entries.
+func (l *Log) SetAsync(bufferSize int) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	old := l.async
+	l.async = nil
+	l.mu.Unlock()
+	if old != nil {
+		old.close()
+	}
+	if bufferSize <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.async = newAsyncQueue(l.writeMu, bufferSize, l.asyncDrop, l.dropped)
+}
+
+// SetAsyncDropPolicy controls what an asynchronous log does when its buffer is full. If drop is false,
+// the default, the logging call waits until there is room in the buffer. If drop is true, the entry is
+// discarded and counted; see Dropped.
+func (l *Log) SetAsyncDropPolicy(drop bool) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.asyncDrop = drop
+	if l.async != nil {
+		l.async.setDrop(drop)
+	}
+}
+
+// Dropped returns the number of entries an asynchronous log has discarded because its buffer was full.
+func (l *Log) Dropped() uint64 {
+	if l == nil {
+		return 0
+	}
+	return atomic.LoadUint64(l.dropped)
+}
+
+// Flush waits until all entries queued by an asynchronous log have been written, and then flushes the
+// outputs that have a Flush() error method, such as a *bufio.Writer, returning their joined errors.
+// Close calls Flush before closing the outputs, and FATAL and PANIC entries are flushed before the
+// program exits or panics.
+func (l *Log) Flush() error {
+	if l == nil {
 		return nil
 	}
-	return l.writeEntry("INFO", format, args...)
+	l.mu.Lock()
+	q := l.async
+	l.mu.Unlock()
+	if q != nil {
+		q.flush()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.flushOutputs()
 }
 
-func (l *Log) Warning(format string, args ...interface{}) error {
-	if l.logLevel&LevelWarning == 0 {
+// Sync flushes the output, as described for Flush, and then commits the entries written so far to
+// stable storage by calling Sync on each output that has it, such as the *os.File opened by
+// SetOutputFile or NewFileLog, or a rotating file. It is a no-op returning nil for other outputs.
+func (l *Log) Sync() error {
+	if l == nil {
 		return nil
 	}
-	return l.writeEntry("WARNING", format, args...)
+	err := l.Flush()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.writeMu.Lock()
+	defer l.writeMu.Unlock()
+	return errors.Join(err, syncWriters(l.outputs()...))
 }
 
-func (l *Log) Error(format string, args ...interface{}) error {
-	if l.logLevel&LevelError == 0 {
+// flushOutputs flushes the outputs that buffer. l.mu must be held, and l.writeMu must not be.
+func (l *Log) flushOutputs() error {
+	l.writeMu.Lock()
+	defer l.writeMu.Unlock()
+	return flushWriters(l.outputs()...)
+}
+
+// outputs returns the default output followed by the level outputs. l.mu must be held.
+func (l *Log) outputs() []io.Writer {
+	ws := []io.Writer{l.output}
+	for _, o := range l.levelOutputs {
+		ws = append(ws, o.w)
+	}
+	return ws
+}
+
+func (l *Log) SetOutputFile(f string) error {
+	if l == nil {
 		return nil
 	}
-	return l.writeEntry("ERROR", format, args...)
+	w, err := openFile(f)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.output = w
+	l.outputFile = f
+	return nil
 }
 
-func (l *Log) Fatal(format string, args ...interface{}) error {
-	if l.logLevel&LevelFatal == 0 {
+// Reopen reopens the file set by SetOutputFile and closes the previous file handle, so that entries go
+// to a fresh file after an external tool such as logrotate has moved or truncated the file, typically
+// in response to SIGHUP. The new file is opened before the old one is closed, and no entries are written
+// in between. With SetAsync, entries queued before the call are written to the old file before it is
+// closed. If the output was not set by SetOutputFile, Reopen returns ErrNotReopenable. If the file
+// can't be reopened, the error is returned and the previous file handle remains in use.
+func (l *Log) Reopen() error {
+	if l == nil {
 		return nil
 	}
-	err := l.writeEntry("FATAL", format, args...)
-	os.Exit(1)
-	return err // won't actually execute
+	l.mu.Lock()
+	if l.outputFile == "" {
+		l.mu.Unlock()
+		return ErrNotReopenable
+	}
+	w, err := openFile(l.outputFile)
+	if err != nil {
+		l.mu.Unlock()
+		return err
+	}
+	if b, ok := l.output.(*batchWriter); ok {
+		defer l.mu.Unlock()
+		return b.reset(w)
+	}
+	old := l.output.(io.Closer)
+	l.output = w
+	q := l.async
+	l.mu.Unlock()
+	if q != nil {
+		q.flush() // queued entries still refer to the old file
+	}
+	return old.Close()
 }
 
-func (l *Log) Panic(format string, args ...interface{}) error {
-	if l.logLevel&LevelPanic == 0 {
+func openFile(f string) (*os.File, error) {
+	return os.OpenFile(f, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+}
+
+// SetOutputRotatingFile directs log entries to the file f. When writing an entry would take the file
+// past maxBytes, f is renamed to f.1 (after f.1 is renamed to f.2 and so on) and a new f is created.
+// At most maxBackups renamed files are kept; with maxBackups of zero f is simply recreated. An entry is
+// never split across files, so a single entry larger than maxBytes is written whole to a fresh file.
+// Rotation happens while the entry is being written, and any rotation error is returned by the logging
+// call. A maxBytes of zero or less, or a negative maxBackups, is an error.
+func (l *Log) SetOutputRotatingFile(f string, maxBytes int64, maxBackups int) error {
+	if l == nil {
 		return nil
 	}
-	err := l.writeEntry("PANIC", format, args...)
-	panic(fmt.Sprintf(format, args...))
-	return err // won't actually execute
+	w, err := openRotatingFile(f, maxBytes, maxBackups)
+	if err != nil {
+		return err
+	}
+	l.SetOutput(w)
+	return nil
 }
 
-func (l *Log) Custom(level string, format string, args ...interface{}) error {
-	if l.logLevel&LevelCustom == 0 {
+// SetOutputRotatingByTime directs log entries to a new file for each interval, named by formatting the
+// start of the interval with the file name of pathTemplate as a time layout, eg. "logs/app-2006-01-02.log"
+// with an interval of 24 * time.Hour for daily files. The directory is used as is. Intervals are aligned to multiples of interval since the zero
+// time, in UTC, so daily files start at midnight UTC. The file is switched on the first write after an
+// interval ends, and an existing file for the interval is appended to. Old files are never removed.
+func (l *Log) SetOutputRotatingByTime(pathTemplate string, interval time.Duration) error {
+	if l == nil {
 		return nil
 	}
-	return l.writeEntry(level, format, args...)
+	l.mu.Lock()
+	now := l.clock
+	l.mu.Unlock()
+	w, err := openTimeRotatingFile(pathTemplate, interval, now)
+	if err != nil {
+		return err
+	}
+	l.SetOutput(w)
+	return nil
 }
 
-func (l *Log) writeEntry(level string, format string, args ...interface{}) error {
-	_, err := fmt.Fprintf(l.output, "%s\t%s\t%s\n", l.timestamp(), level, fmt.Sprintf(format, args...))
-	return err
+func (l *Log) SetTimestamp(f func() string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.timestamp = f
+}
+
+// SetTimeFormat replaces the timestamp func with one that formats the current time in loc with layout,
+// eg. SetTimeFormat(time.RFC1123, time.Local). An empty layout means time.RFC3339Nano and a nil loc
+// means UTC, so SetTimeFormat("", nil) restores the default timestamps. Use SetTimestamp for full control.
+func (l *Log) SetTimeFormat(layout string, loc *time.Location) {
+	if l == nil {
+		return
+	}
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.timestamp = nil
+	l.timeLayout, l.timeLoc = layout, loc
+}
+
+// SetClock replaces time.Now as the source of the current time for l's time-dependent features: the
+// default and SetTimeFormat timestamps, the files of SetOutputRotatingByTime, the windows of SetDedup
+// and the windows of a RepeatSampler set by SetSampler. Advancing a fake clock makes them deterministic
+// in tests, although the SetDedup timer that writes a pending summary still runs in real time. A func
+// set by SetTimestamp ignores the clock. A nil now restores time.Now.
+func (l *Log) SetClock(now func() time.Time) {
+	if l == nil {
+		return
+	}
+	if now == nil {
+		now = time.Now
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clock = now
+	if c, ok := l.sampler.(clockSetter); ok {
+		c.setClock(now)
+	}
+	l.configureOutputs()
+}
+
+// clockSetter is implemented by Samplers that use the clock set by SetClock.
+type clockSetter interface {
+	setClock(now func() time.Time)
+}
+
+// formatTime returns the timestamp for a new entry. l.mu must be held.
+func (l *Log) formatTime() string {
+	if l.noTimestamp {
+		return ""
+	}
+	if l.timestamp != nil {
+		return l.timestamp()
+	}
+	return l.clock().In(l.timeLoc).Format(l.timeLayout)
+}
+
+func (l *Log) SetCrashFile(f string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.crashFile = f
+}
+
+func (l *Log) SetFatalAsError(b bool) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fatalAsError = b
+}
+
+func (l *Log) SetFraming(f Framing) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.framing = f
+}
+
+// SetFlushEachEntry controls whether an output that buffers, such as a *bufio.Writer, is flushed after
+// every entry, so that a consumer tailing the output sees each entry as soon as it is logged rather than
+// when the buffer fills. Each entry is always written with a single Write while holding the write lock,
+// so entries from concurrent goroutines never interleave: with the JSON format and the default
+// terminator, the output is valid JSON Lines (NDJSON), one object per line. Flushing each entry also
+// writes each one immediately when batching with SetBatching. It is off by default.
+func (l *Log) SetFlushEachEntry(b bool) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.flushEach = b
+}
+
+// SetTimestampEnabled controls whether entries include a timestamp. Disabling it suits outputs that add
+// their own, such as syslog or journald: the text format then starts each entry with the level, and
+// the JSON and logfmt formats leave out the "ts" key. CSV entries keep an empty timestamp column, so
+// that the columns don't shift. Timestamps are enabled by default.
+func (l *Log) SetTimestampEnabled(b bool) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.noTimestamp = !b
+}
+
+// SetPrefix sets a prefix, such as "[auth] ", that is written at the start of the message of each
+// entry, after the timestamp and level. Since it is part of the message it appears in every format,
+// eg. in the "msg" value of the JSON format, and is passed to hooks and sinks. The prefix is empty by
+// default, and is inherited by clones and loggers returned by WithFields.
+func (l *Log) SetPrefix(p string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prefix = p
+}
+
+// SetTerminator sets the string written after each entry, "\n" by default. Use "\r\n" for consumers
+// that expect CRLF line endings, or "" for outputs that delimit entries themselves. Only the end of the
+// entry is affected: newlines within a multi-line message or stack trace are written as they are,
+// unless TextFormatter.EscapeNewlines is set. The terminator isn't written with FramingLengthPrefixed,
+// and doesn't apply to a Sink.
+func (l *Log) SetTerminator(s string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.terminator = s
+}
+
+func (l *Log) SetFormat(f Formatter) {
+	if l == nil {
+		return
+	}
+	if f == nil {
+		f = &TextFormatter{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.formatter = f
+}
+
+// SetFieldSeparator replaces the tab that separates the timestamp, level, message and fields of each
+// entry with sep, eg. " ". It only applies to the text format, and returns an error if the log uses
+// another Formatter, or if sep is empty or contains a line break, which would break line-oriented
+// parsing of the output. The separator is reset by SetFormat.
+func (l *Log) SetFieldSeparator(sep string) error {
+	if l == nil {
+		return nil
+	}
+	if sep == "" || strings.ContainsAny(sep, "\r\n") {
+		return fmt.Errorf("log: invalid field separator %q", sep)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	tf, ok := l.formatter.(*TextFormatter)
+	if !ok {
+		return errors.New("log: the field separator only applies to a TextFormatter")
+	}
+	c := *tf // the formatter may be shared with clones
+	c.Separator = sep
+	l.formatter = &c
+	return nil
+}
+
+// SetColor controls whether the level of each entry is wrapped in ANSI color codes, as described for
+// TextFormatter.Color. It only applies to the text format and is a no-op for other Formatters, whose
+// output is meant to be parsed. Color is off by default, and is reset by SetFormat. Use SetColorAuto
+// to enable it only when the output is a terminal.
+func (l *Log) SetColor(b bool) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.setColor(b)
+}
+
+// SetColorAuto calls SetColor(true) if the output is an *os.File connected to a terminal, such as
+// os.Stderr in an interactive session, and SetColor(false) otherwise.
+func (l *Log) SetColorAuto() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.setColor(isTerminal(l.output))
+}
+
+// setColor sets the Color option of the text formatter. l.mu must be held.
+func (l *Log) setColor(b bool) {
+	if tf, ok := l.formatter.(*TextFormatter); ok && tf.Color != b {
+		c := *tf // the formatter may be shared with clones
+		c.Color = b
+		l.formatter = &c
+	}
+}
+
+func (l *Log) SetIncludeCaller(b bool) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.withCaller = b
+}
+
+// SetErrorHandler sets f to be called with the error whenever writing an entry to the output fails,
+// including entries written in the background by an asynchronous log, whose errors can't otherwise be
+// reported. It is called after the logging call has released its locks, but f should not write to l,
+// since a failing output would call it again; report the error some other way, such as to os.Stderr
+// or a metric. A nil f removes the handler.
+func (l *Log) SetErrorHandler(f func(error)) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errorHandler = f
+	l.configureOutputs()
+}
+
+// SetCompressBackups controls whether the files rotated out by SetOutputRotatingFile and
+// SetOutputRotatingByTime are compressed with gzip, to path.1.gz and so on for SetOutputRotatingFile
+// and to the file name plus ".gz" for SetOutputRotatingByTime. Files are compressed by a background
+// goroutine, so logging isn't delayed, except that a size-based rotation waits for the previous
+// backup to be compressed before shifting the backups. Close waits for compression to finish.
+// Compression errors are passed to the error handler set by SetErrorHandler. It is off by default.
+func (l *Log) SetCompressBackups(b bool) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.compress = b
+	l.configureOutputs()
+}
+
+// SetStackTraceLevel sets the levels, which may combine several levels, whose entries include a "stack"
+// field with the stack trace of the logging call, starting at the function that made it. The default
+// is LevelFatal|LevelPanic, and LevelNone turns stack traces off. The stack spans several lines; set
+// TextFormatter.EscapeNewlines to keep each text entry on a single line.
+func (l *Log) SetStackTraceLevel(levels Level) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stackLevels = levels
+}
+
+// SetMaxAge removes the backups of SetOutputRotatingFile that were last modified more than d ago, as
+// given by the clock set by SetClock. Backups are checked each time the file is rotated, and removed
+// when they are too old or when there are more than maxBackups, whichever comes first. Errors
+// removing backups are passed to the error handler set by SetErrorHandler, and don't stop the entry
+// being written. A d of zero or less, the default, keeps backups regardless of age.
+func (l *Log) SetMaxAge(d time.Duration) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxAge = d
+	l.configureOutputs()
+}
+
+// configureOutputs passes the settings in rotateConfig to the outputs that use them. It must be
+// called, with l.mu held, whenever the outputs or those settings change.
+func (l *Log) configureOutputs() {
+	c := rotateConfig{now: l.clock, onError: l.errorHandler, compress: l.compress, maxAge: l.maxAge}
+	configureOutput(l.output, c)
+	for _, o := range l.levelOutputs {
+		configureOutput(o.w, c)
+	}
+}
+
+// Clone returns a new Log with a copy of l's settings, including its level, timestamp, format, fields
+// and context keys. Changing a setting of either Log doesn't affect the other. Unlike a Log derived by
+// WithFields, the clone also starts afresh with the state that tracks what is written: its own Counts,
+// Dropped, Sampled and RateLimited counts, its own rate limit bucket and deduplication, its own
+// RepeatSampler if l has one, and, if l is asynchronous, its own queue and background goroutine, which
+// SetAsync(0) stops without closing the outputs. Any other Sampler is shared, as are hooks and the
+// functions set by SetTimestamp, SetErrorHandler and the like. The output writers are shared rather
+// than copied, since they are real sinks: entries from both Logs are written to the same outputs
+// without interleaving, and closing, reopening or rotating the output of one Log affects the output the
+// other is writing to. To give the clone its own destination, call SetOutput on it.
+func (l *Log) Clone() *Log {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	c := *l
+	c.mu = &sync.Mutex{}
+	c.dropped, c.sampled, c.rateLimited = new(uint64), new(uint64), new(uint64)
+	c.counts = new(levelCounts)
+	if l.limiter != nil {
+		c.limiter = &rateLimiter{rate: l.limiter.rate}
+	}
+	if l.dedup != nil {
+		c.dedup = &dedup{window: l.dedup.window}
+	}
+	if s, ok := l.sampler.(*RepeatSampler); ok {
+		c.sampler = s.clone()
+	}
+	if l.async != nil {
+		c.async = newAsyncQueue(c.writeMu, cap(l.async.entries), l.asyncDrop, c.dropped)
+	}
+	return &c
+}
+
+func (l *Log) SetExitFunc(f func(int)) {
+	if l == nil {
+		return
+	}
+	if f == nil {
+		f = os.Exit
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.exit = f
+}
+
+// SetOutputEventLog directs log output to the Windows Event Log under the given event source. ERROR,
+// FATAL and PANIC entries are reported as error events, WARNING entries as warning events and everything
+// else as information events. The source does not have to be registered, but unless it is (eg. with
+// New-EventLog or eventcreate) the Event Viewer will prefix each entry with a "description cannot be
+// found" notice. Close deregisters the event source. On other platforms it returns ErrEventLogUnsupported.
+func (l *Log) SetOutputEventLog(source string) error {
+	if l == nil {
+		return nil
+	}
+	w, err := openEventLog(source)
+	if err != nil {
+		return err
+	}
+	l.SetOutput(w)
+	return nil
+}
+
+func (l *Log) Enabled(level Level) bool {
+	if l == nil {
+		return false
+	}
+	return l.level()&level != 0
+}
+
+// SetSyslogOutput directs log output to the local syslog daemon, with the given tag and the LOG_USER
+// facility. Each entry is sent with the syslog severity matching its level: TRACE and DEBUG as LOG_DEBUG, INFO and
+// custom entries as LOG_INFO, WARNING as LOG_WARNING, ERROR as LOG_ERR and FATAL and PANIC as LOG_CRIT.
+// Close closes the connection to the daemon. On Windows and Plan 9 it returns ErrSyslogUnsupported.
+func (l *Log) SetSyslogOutput(tag string) error {
+	if l == nil {
+		return nil
+	}
+	w, err := openSyslog(tag)
+	if err != nil {
+		return err
+	}
+	l.SetOutput(w)
+	return nil
+}
+
+// SetTCPOutput directs log output to a TCP connection to addr, such as a Logstash collector, and returns
+// an error if the connection can't be made within dialTimeout. If writing an entry fails, the logging
+// call returns the error and the connection is dropped; the next entry redials addr, without retrying in
+// a loop. Entries are not buffered while disconnected: they fail, unless the log is asynchronous (see
+// SetAsync), in which case they wait in the buffer or are dropped as set by SetAsyncDropPolicy. Close
+// closes the connection.
+func (l *Log) SetTCPOutput(addr string, dialTimeout time.Duration) error {
+	if l == nil {
+		return nil
+	}
+	w, err := dialTCP(addr, dialTimeout)
+	if err != nil {
+		return err
+	}
+	l.SetOutput(w)
+	return nil
+}
+
+// SetGELFOutput sends entries to a Graylog GELF UDP input at addr, such as "graylog:12201", formatted
+// by NewGELFFormatter. An entry larger than 1420 bytes, which might not fit in a single datagram, is
+// split into GELF chunks; entries needing more than 128 chunks fail. Since UDP is connectionless, entries
+// sent while nothing is listening are lost without an error. Close closes the socket.
+func (l *Log) SetGELFOutput(addr string) error {
+	if l == nil {
+		return nil
+	}
+	w, err := dialGELF(addr)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.output = w
+	l.outputFile = ""
+	l.formatter = NewGELFFormatter()
+	return nil
+}
+
+func (l *Log) Trace(format string, args ...interface{}) error {
+	if !l.Enabled(LevelTrace) {
+		return nil
+	}
+	return l.writeEntry(LevelTrace, "TRACE", format, args...)
+}
+
+func (l *Log) Debug(format string, args ...interface{}) error {
+	if !l.Enabled(LevelDebug) {
+		return nil
+	}
+	return l.writeEntry(LevelDebug, "DEBUG", format, args...)
+}
+
+func (l *Log) Info(format string, args ...interface{}) error {
+	if !l.Enabled(LevelInfo) {
+		return nil
+	}
+	return l.writeEntry(LevelInfo, "INFO", format, args...)
+}
+
+func (l *Log) Warning(format string, args ...interface{}) error {
+	if !l.Enabled(LevelWarning) {
+		return nil
+	}
+	return l.writeEntry(LevelWarning, "WARNING", format, args...)
+}
+
+func (l *Log) Error(format string, args ...interface{}) error {
+	if !l.Enabled(LevelError) {
+		return nil
+	}
+	return l.writeEntry(LevelError, "ERROR", format, args...)
+}
+
+// ErrorIf writes an ERROR entry if err is not nil, with ": " and the error appended to the formatted
+// message, and returns err unchanged so that an error can be logged and propagated in one statement:
+//
+//	return l.ErrorIf(f.Close(), "closing %s", name)
+//
+// Nothing is written if err is nil. An error writing the entry isn't returned, but is still passed to
+// the error handler set by SetErrorHandler.
+func (l *Log) ErrorIf(err error, format string, args ...interface{}) error {
+	return l.logIf(LevelError, "ERROR", err, format, args)
+}
+
+// WarnIf is like ErrorIf, but writes a WARNING entry.
+func (l *Log) WarnIf(err error, format string, args ...interface{}) error {
+	return l.logIf(LevelWarning, "WARNING", err, format, args)
+}
+
+// logIf implements ErrorIf and WarnIf.
+func (l *Log) logIf(level Level, name string, err error, format string, args []interface{}) error {
+	if err == nil || !l.Enabled(level) {
+		return err
+	}
+	l.writeEntry(level, name, format+": %v", append(args[:len(args):len(args)], err)...)
+	return err
+}
+
+func (l *Log) Fatal(format string, args ...interface{}) error {
+	if !l.Enabled(LevelFatal) {
+		return nil
+	}
+	if l.fatalAsError {
+		return l.writeEntry(LevelFatal, "FATAL", format, args...)
+	}
+	return l.fatalMsg(fmt.Sprintf(format, args...))
+}
+
+// fatalMsg writes a FATAL entry for msg, which has no format, and exits, unless SetFatalAsError is set.
+func (l *Log) fatalMsg(msg string) error {
+	if l.fatalAsError {
+		return l.writeLine(LevelFatal, "FATAL", msg)
+	}
+	err := l.writeMessage(LevelFatal, "FATAL", msg, true)
+	l.mu.Lock()
+	exit := l.exit
+	l.mu.Unlock()
+	exit(1)
+	return err // only reached if the exit func returns
+}
+
+func (l *Log) Panic(format string, args ...interface{}) error {
+	if !l.Enabled(LevelPanic) {
+		return nil
+	}
+	if l.fatalAsError {
+		return l.writeEntry(LevelPanic, "PANIC", format, args...)
+	}
+	return l.panicMsg(fmt.Sprintf(format, args...))
+}
+
+// panicMsg writes a PANIC entry for msg, which has no format, and panics, unless SetFatalAsError is set.
+func (l *Log) panicMsg(msg string) error {
+	if l.fatalAsError {
+		return l.writeLine(LevelPanic, "PANIC", msg)
+	}
+	l.writeMessage(LevelPanic, "PANIC", msg, true)
+	panic(msg)
+}
+
+func (l *Log) Custom(level string, format string, args ...interface{}) error {
+	if !l.Enabled(LevelCustom) {
+		return nil
+	}
+	return l.writeEntry(LevelCustom, level, format, args...)
+}
+
+func (l *Log) DebugFunc(f func() string) error {
+	if !l.Enabled(LevelDebug) {
+		return nil
+	}
+	return l.writeMessage(LevelDebug, "DEBUG", f(), false)
+}
+
+func (l *Log) InfoFunc(f func() string) error {
+	if !l.Enabled(LevelInfo) {
+		return nil
+	}
+	return l.writeMessage(LevelInfo, "INFO", f(), false)
+}
+
+func (l *Log) WarningFunc(f func() string) error {
+	if !l.Enabled(LevelWarning) {
+		return nil
+	}
+	return l.writeMessage(LevelWarning, "WARNING", f(), false)
+}
+
+func (l *Log) ErrorFunc(f func() string) error {
+	if !l.Enabled(LevelError) {
+		return nil
+	}
+	return l.writeMessage(LevelError, "ERROR", f(), false)
+}
+
+func (l *Log) Debugln(args ...interface{}) error {
+	if !l.Enabled(LevelDebug) {
+		return nil
+	}
+	return l.writeLine(LevelDebug, "DEBUG", sprintln(args...))
+}
+
+func (l *Log) Infoln(args ...interface{}) error {
+	if !l.Enabled(LevelInfo) {
+		return nil
+	}
+	return l.writeLine(LevelInfo, "INFO", sprintln(args...))
+}
+
+func (l *Log) Warningln(args ...interface{}) error {
+	if !l.Enabled(LevelWarning) {
+		return nil
+	}
+	return l.writeLine(LevelWarning, "WARNING", sprintln(args...))
+}
+
+func (l *Log) Errorln(args ...interface{}) error {
+	if !l.Enabled(LevelError) {
+		return nil
+	}
+	return l.writeLine(LevelError, "ERROR", sprintln(args...))
+}
+
+func (l *Log) Fatalln(args ...interface{}) error {
+	if !l.Enabled(LevelFatal) {
+		return nil
+	}
+	return l.fatalMsg(sprintln(args...))
+}
+
+func (l *Log) Panicln(args ...interface{}) error {
+	if !l.Enabled(LevelPanic) {
+		return nil
+	}
+	return l.panicMsg(sprintln(args...))
+}
+
+// sprintln formats args like fmt.Sprintln, without the trailing newline.
+func sprintln(args ...interface{}) string {
+	s := fmt.Sprintln(args...)
+	return s[:len(s)-1]
+}
+
+// writeLine writes an entry for msg, which has no format, so it is sampled by msg itself.
+func (l *Log) writeLine(level Level, name string, msg string) error {
+	if !l.sample(level, msg) || l.discards(level) {
+		return nil
+	}
+	return l.writeMessage(level, name, msg, false)
+}
+
+func (l *Log) writeEntry(level Level, name string, format string, args ...interface{}) error {
+	if !l.sample(level, format) || l.discards(level) {
+		return nil
+	}
+	return l.writeMessage(level, name, fmt.Sprintf(format, args...), false)
+}
+
+// writeMessage writes an entry for the already formatted msg, and also writes it to the crash
+// file if crash is set. The message is formatted by the caller so that the lock is only held
+// while the entry is assembled and written.
+func (l *Log) writeMessage(level Level, name string, msg string, crash bool) error {
+	msg, keep := l.filter(level, msg)
+	if !keep || !l.allow(level) {
+		return nil
+	}
+	var err error
+	if crash {
+		err = l.flushDedup()
+	} else if ok, derr := l.dedupe(level, name, msg); !ok {
+		return nil
+	} else {
+		err = derr
+	}
+	if werr := l.emit(level, name, msg, crash); werr != nil {
+		return werr
+	}
+	return err // the error writing a summary of repeated entries
+}
+
+// emit writes the entry for writeMessage, without deduplicating it.
+func (l *Log) emit(level Level, name string, msg string, crash bool) error {
+	if crash {
+		l.Flush() // entries queued before the crash must be written first
+	}
+	l.mu.Lock()
+	e, err := l.newEntry(level, name, msg)
+	if err != nil {
+		l.mu.Unlock()
+		return err
+	}
+	l.counts.add(level)
+	// the entry is queued with l.mu held, so that Reopen can't swap the output it was made for between
+	// the entry being made and queued
+	if q := l.async; q != nil && !crash && q.send(e) {
+		l.mu.Unlock()
+		return nil
+	}
+	l.writeMu.Lock()
+	err = e.write()
+	l.writeMu.Unlock()
+	if crash {
+		if ferr := l.flushOutputs(); err == nil {
+			err = ferr
+		}
+		if cerr := l.writeCrash(e.formatted(l.formatter), e.hasStack); err == nil {
+			err = cerr
+		}
+	}
+	e.release()
+	l.mu.Unlock()
+	e.written(err)
+	return err
+}
+
+// newEntry formats msg and the fields into an entry for the current output. level is the entry's
+// Level bit and name is the level name written in the entry. l.mu must be held.
+func (l *Log) newEntry(level Level, name string, msg string) (*entry, error) {
+	w := l.outputFor(level)
+	_, toSink := w.(sinkOutput)
+	fields, owned := l.fields, false
+	var pooled map[string]interface{}
+	if len(l.pairs) > 0 {
+		if toSink || !builtinFormatter(l.formatter) {
+			fields = make(map[string]interface{}, len(l.fields)+len(l.pairs))
+		} else {
+			pooled = fieldsPool.Get().(map[string]interface{})
+			fields = pooled
+			defer releaseFields(pooled)
+		}
+		l.mergeFields(fields)
+		owned = true
+	}
+	if l.withCaller {
+		fields, owned = setField(fields, owned, "caller", caller()), true
+	}
+	hasStack := l.hasStack
+	if _, ok := fields["stack"]; !ok && l.stackLevels&level != 0 {
+		fields = setField(fields, owned, "stack", stack())
+		hasStack = true
+	}
+	if l.redactors != nil {
+		msg = l.redact(msg)
+	}
+	msg = l.prefix + msg
+	ts := l.formatTime()
+	if s, ok := w.(sinkOutput); ok {
+		return &entry{
+			sink:      s.Sink,
+			level:     level,
+			onError:   l.errorHandler,
+			hooks:     l.hooksFor(level),
+			name:      name,
+			timestamp: ts,
+			msg:       msg,
+			fields:    fields,
+			hasStack:  hasStack,
+		}, nil
+	}
+	buf := linePool.Get().(*bytes.Buffer)
+	var err error
+	if bf, ok := l.formatter.(bufferFormatter); ok {
+		err = bf.formatTo(buf, ts, name, msg, fields)
+	} else {
+		var b []byte
+		if b, err = l.formatter.Format(ts, name, msg, fields); err == nil {
+			buf.Write(b)
+		}
+	}
+	if err != nil {
+		buf.Reset()
+		linePool.Put(buf)
+		return nil, err
+	}
+	if l.framing != FramingLengthPrefixed {
+		buf.WriteString(l.terminator)
+	}
+	e := &entry{
+		w:         w,
+		framing:   l.framing,
+		level:     level,
+		buf:       buf,
+		line:      buf.Bytes(),
+		onError:   l.errorHandler,
+		flushEach: l.flushEach,
+		hasStack:  hasStack,
+	}
+	if e.hooks = l.hooksFor(level); e.hooks != nil {
+		e.name, e.timestamp, e.msg = name, ts, msg
+	}
+	return e, nil
+}
+
+// discards reports whether entries of level are written to io.Discard with no hooks to fire, in which
+// case they are only checked against the rate limit and counted, without being formatted. Entries that
+// a filter or deduplication would have to see formatted are never discarded here.
+func (l *Log) discards(level Level) bool {
+	l.mu.Lock()
+	discard := l.outputFor(level) == io.Discard && l.hooksFor(level) == nil && l.filters == nil && l.dedup == nil
+	l.mu.Unlock()
+	if !discard {
+		return false
+	}
+	if l.allow(level) {
+		l.counts.add(level)
+	}
+	return true
+}
+
+// outputFor returns the writer for entries of level. l.mu must be held.
+func (l *Log) outputFor(level Level) io.Writer {
+	for _, o := range l.levelOutputs {
+		if o.mask&level != 0 {
+			return o.w
+		}
+	}
+	return l.output
+}
+
+// entry is a formatted log entry, ready to be written to the output it was formatted for.
+type entry struct {
+	w       io.Writer
+	framing Framing
+	level   Level
+	line    []byte
+	buf     *bytes.Buffer // holds line until release
+	onError func(error)   // the Log's error handler, if any
+	flushed chan struct{} // set only for the flush markers of an asyncQueue
+
+	flushEach bool // flush w after writing the entry; see SetFlushEachEntry
+	hasStack  bool // the entry has a "stack" field with the stack trace of the logging call
+
+	// The hooks to fire once the entry is written, and the parts of the entry they are passed.
+	hooks                []Hook
+	name, timestamp, msg string
+
+	// sink is set instead of w and line for entries written to a Sink, along with all the parts.
+	sink   Sink
+	fields map[string]interface{}
+}
+
+// linePool holds the buffers that entries are assembled in, so that each entry doesn't allocate one.
+var linePool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+// maxPooledLine is the capacity above which a buffer isn't returned to linePool, so that an occasional
+// huge entry doesn't pin its memory.
+const maxPooledLine = 64 << 10
+
+// release returns the entry's buffer to linePool, once the entry has been written or dropped. The line
+// must not be used afterwards.
+func (e *entry) release() {
+	if e.buf == nil {
+		return
+	}
+	if e.buf.Cap() <= maxPooledLine {
+		e.buf.Reset()
+		linePool.Put(e.buf)
+	}
+	e.buf, e.line = nil, nil
+}
+
+// formatted returns the entry's line, formatting it with f if it was left unformatted for a Sink.
+func (e *entry) formatted(f Formatter) []byte {
+	if e.sink == nil {
+		return e.line
+	}
+	b, err := f.Format(e.timestamp, e.name, e.msg, e.fields)
+	if err != nil {
+		return []byte(e.msg + "\n")
+	}
+	return append(b, '\n')
+}
+
+// written reports the error, if any, from writing the entry and then fires its hooks. No locks may
+// be held.
+func (e *entry) written(err error) {
+	e.reportError(err)
+	e.fireHooks()
+}
+
+// reportError passes a non-nil err to the error handler, if there is one. No locks may be held, so
+// that the handler can't deadlock the Log.
+func (e *entry) reportError(err error) {
+	if err != nil && e.onError != nil {
+		e.onError(err)
+	}
+}
+
+// levelWriter is implemented by outputs that need to know the level of each entry.
+type levelWriter interface {
+	writeLevel(level Level, p []byte) error
+}
+
+// write writes the entry. The writeMu of the Log that formatted it must be held.
+func (e *entry) write() error {
+	if e.sink != nil {
+		return e.sink.Write(e.level, e.name, e.timestamp, e.msg, e.fields)
+	}
+	p := e.line
+	if e.framing == FramingLengthPrefixed {
+		p = frame(e.line)
+	}
+	err := writeOutput(e.w, e.level, p)
+	if e.flushEach {
+		if ferr := flushWriters(e.w); err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+// writeOutput writes p, an entry at level, to w.
+func writeOutput(w io.Writer, level Level, p []byte) error {
+	if lw, ok := w.(levelWriter); ok {
+		return lw.writeLevel(level, p)
+	}
+	_, err := w.Write(p)
+	return err
+}
+
+// writeCrash appends entry to the crash file, if any, and syncs it to disk. The current stack is
+// appended too, unless hasStack reports that entry already has a "stack" field added by the Log with
+// the stack trace of the crash; a "stack" field set by the caller doesn't count. l.mu must be held.
+func (l *Log) writeCrash(entry []byte, hasStack bool) error {
+	if l.crashFile == "" {
+		return nil
+	}
+	w, err := openFile(l.crashFile)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	entry = bytes.TrimRight(entry, "\r\n")
+	if hasStack {
+		_, err = fmt.Fprintf(w, "%s\n", entry)
+	} else {
+		_, err = fmt.Fprintf(w, "%s\n%s\n", entry, debug.Stack())
+	}
+	if err != nil {
+		return err
+	}
+	return w.Sync()
+}
+
+// logFunc returns the entry method for level, which should be a single Level bit. Unknown levels map to Info.
+func (l *Log) logFunc(level Level) func(format string, args ...interface{}) error {
+	switch level {
+	case LevelTrace:
+		return l.Trace
+	case LevelDebug:
+		return l.Debug
+	case LevelWarning:
+		return l.Warning
+	case LevelError:
+		return l.Error
+	case LevelFatal:
+		return l.Fatal
+	case LevelPanic:
+		return l.Panic
+	}
+	return l.Info
+}
+
+// lineFunc returns a function that writes a message that has no format, such as a line written to a
+// Writer, as an entry at level, which should be a single Level bit. Unknown levels map to Info.
+func (l *Log) lineFunc(level Level) func(msg string) error {
+	name := "INFO"
+	switch level {
+	case LevelTrace:
+		name = "TRACE"
+	case LevelDebug:
+		name = "DEBUG"
+	case LevelWarning:
+		name = "WARNING"
+	case LevelError:
+		name = "ERROR"
+	case LevelFatal:
+		name = "FATAL"
+	case LevelPanic:
+		name = "PANIC"
+	default:
+		level = LevelInfo
+	}
+	return func(msg string) error {
+		switch {
+		case !l.Enabled(level):
+			return nil
+		case level == LevelFatal:
+			return l.fatalMsg(msg)
+		case level == LevelPanic:
+			return l.panicMsg(msg)
+		}
+		return l.writeLine(level, name, msg)
+	}
 }
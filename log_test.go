@@ -1,12 +1,21 @@
 package log_test
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/Syncbak-Git/log"
 )
@@ -54,6 +63,71 @@ func TestLog(t *testing.T) {
 	t.Error("Panic didn't panic")
 }
 
+func TestInitFromEnv(t *testing.T) {
+	defer log.SetLogLevel(log.LevelAll)
+	t.Setenv("TEST_LOG_LEVEL", "debug,error")
+	if err := log.InitFromEnv("TEST_LOG_LEVEL"); err != nil {
+		t.Fatalf("InitFromEnv failed: %s", err)
+	}
+	if !log.Enabled(log.LevelDebug) || !log.Enabled(log.LevelError) || log.Enabled(log.LevelInfo) {
+		t.Error("Expected only DEBUG and ERROR to be enabled")
+	}
+	t.Setenv("TEST_LOG_LEVEL", "debug,loud")
+	if err := log.InitFromEnv("TEST_LOG_LEVEL"); err == nil {
+		t.Error("Expected an error for a malformed level")
+	}
+	if err := log.InitFromEnv("TEST_LOG_LEVEL_UNSET"); err != nil {
+		t.Errorf("Expected no error for an unset variable, got %s", err)
+	}
+	if !log.Enabled(log.LevelDebug) || log.Enabled(log.LevelInfo) {
+		t.Error("Expected the level to be unchanged")
+	}
+}
+
+func TestNilLog(t *testing.T) {
+	var l *log.Log
+	ctx := context.Background()
+	errs := []error{
+		l.Trace("x"), l.Debug("x"), l.Info("x"), l.Warning("x"), l.Error("x"), l.Fatal("x"), l.Panic("x"),
+		l.Custom("AUDIT", "x"), l.Debugln("x"), l.Infoln("x"), l.Warningln("x"), l.Errorln("x"),
+		l.Fatalln("x"), l.Panicln("x"), l.DebugFunc(nil), l.InfoFunc(nil), l.WarningFunc(nil), l.ErrorFunc(nil),
+		l.DebugCtx(ctx, "x"), l.InfoCtx(ctx, "x"), l.WarningCtx(ctx, "x"), l.ErrorCtx(ctx, "x"),
+		l.FatalCtx(ctx, "x"), l.PanicCtx(ctx, "x"), l.InfoKV("x", "k", 1),
+		l.Close(), l.Flush(), l.Sync(), l.Reopen(), l.FlushSampled(), l.FlushRateLimited(),
+		l.SetOutputFile(filepath.Join(t.TempDir(), "app.log")), l.SetFieldSeparator(" "),
+	}
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("call %d returned %v", i, err)
+		}
+	}
+	if err := errors.New("failed"); l.ErrorIf(err, "x") != err {
+		t.Error("Expected ErrorIf to return its error")
+	}
+	l.SetLogLevel(log.LevelAll)
+	l.SetMinLevel(log.LevelDebug)
+	defer l.PushLevel(log.LevelAll)()
+	l.SetOutput(io.Discard)
+	l.SetFormat(&log.JSONFormatter{})
+	l.SetTimestamp(nil)
+	l.SetPrefix("x")
+	l.SetAsync(10)
+	l.SetIncludeCaller(true)
+	l.AddHook(log.LevelAll, nil)
+	l.AddContextKey("k", "k")
+	l.ResetCounts()
+	if l.Enabled(log.LevelError) || l.Dropped() != 0 || l.Sampled() != 0 || l.Counts() != nil {
+		t.Error("Expected a nil Log to be disabled and empty")
+	}
+	if l.WithFields(nil) != nil || l.With("k", 1) != nil || l.WithError(errors.New("x")) != nil || l.Clone() != nil {
+		t.Error("Expected the loggers derived from a nil Log to be nil")
+	}
+	l.Writer(log.LevelInfo).Write([]byte("x\n"))
+	if err := l.LineWriter(log.LevelInfo).Close(); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestClose(t *testing.T) {
 	var buff bytes.Buffer
 	log.SetOutput(&buff)
@@ -87,6 +161,768 @@ func TestClose(t *testing.T) {
 	log.Info("Hello")
 }
 
+func TestSetMinLevel(t *testing.T) {
+	tests := []struct {
+		min     log.Level
+		written []string
+	}{
+		{log.LevelTrace, []string{"TRACE", "DEBUG", "INFO", "WARNING", "ERROR", "TEST"}},
+		{log.LevelDebug, []string{"DEBUG", "INFO", "WARNING", "ERROR", "TEST"}},
+		{log.LevelWarning, []string{"WARNING", "ERROR", "TEST"}},
+		{log.LevelError | log.LevelInfo, []string{"INFO", "WARNING", "ERROR", "TEST"}},
+		{log.LevelPanic, []string{"TEST"}},
+		{log.LevelNone, nil},
+	}
+	for _, tt := range tests {
+		var buff bytes.Buffer
+		l := log.NewLog()
+		l.SetOutput(&buff)
+		l.SetMinLevel(tt.min)
+		l.Trace("Hello")
+		l.Debug("Hello")
+		l.Info("Hello")
+		l.Warning("Hello")
+		l.Error("Hello")
+		l.Custom("TEST", "Hello")
+		if got := strings.Count(buff.String(), "Hello"); got != len(tt.written) {
+			t.Errorf("SetMinLevel(%s): wrote %d entries, want %d: %s", tt.min, got, len(tt.written), buff.String())
+		}
+		for _, w := range tt.written {
+			if !strings.Contains(buff.String(), "\t"+w+"\t") {
+				t.Errorf("SetMinLevel(%s): %s wasn't written", tt.min, w)
+			}
+		}
+	}
+}
+
+func TestPushLevel(t *testing.T) {
+	l := log.NewLog()
+	l.SetLogLevel(log.LevelError)
+	func() {
+		defer l.PushLevel(log.LevelAll)()
+		if !l.Enabled(log.LevelDebug) {
+			t.Error("Expected DEBUG to be enabled by the outer push")
+		}
+		func() {
+			defer l.PushLevel(log.LevelNone)()
+			if l.Enabled(log.LevelError) {
+				t.Error("Expected ERROR to be disabled by the inner push")
+			}
+		}()
+		if !l.Enabled(log.LevelDebug) {
+			t.Error("Expected the inner restore to return to the outer level")
+		}
+	}()
+	if l.Enabled(log.LevelDebug) || !l.Enabled(log.LevelError) {
+		t.Error("Expected the original level to be restored")
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	l := log.NewLog()
+	l.SetOutput(io.Discard)
+	var fired []string
+	l.AddHook(log.LevelError, recordingHook{name: "h", fired: &fired})
+	l.SetTimestamp(func() string { return "ts" })
+	s := &countingStringer{}
+	l.Info("skipped %s", s)
+	l.Error("hooked")
+	if c := l.Counts(); c["INFO"] != 1 || c["ERROR"] != 1 {
+		t.Errorf("Expected discarded entries to be counted, got %v", c)
+	}
+	if s.calls != 0 {
+		t.Error("Expected the discarded entry not to be formatted")
+	}
+	if len(fired) != 1 {
+		t.Errorf("Expected the hook to fire for the discarded entry, got %q", fired)
+	}
+	var buff bytes.Buffer
+	l.SetOutput(&buff)
+	l.Info("written")
+	if !strings.Contains(buff.String(), "\tINFO\twritten\n") {
+		t.Errorf("Expected entries once the output changed, got %q", buff.String())
+	}
+}
+
+func TestDiscardRateLimit(t *testing.T) {
+	counts := func(output io.Writer) (map[string]uint64, uint64) {
+		l := log.NewLog()
+		l.SetOutput(output)
+		l.SetRateLimit(2)
+		for n := 0; n < 5; n++ {
+			l.Info("entry %d", n)
+		}
+		l.AddFilter(func(level log.Level, msg string) (string, bool) { return msg, msg != "dropped" })
+		l.SetRateLimit(0)
+		l.Info("dropped")
+		l.Info("kept")
+		return l.Counts(), l.RateLimited()
+	}
+	discarded, discardedLimited := counts(io.Discard)
+	written, writtenLimited := counts(&bytes.Buffer{})
+	if discarded["INFO"] != 3 || written["INFO"] != 3 {
+		t.Errorf("Counted %d INFO entries with io.Discard and %d otherwise, want 3", discarded["INFO"], written["INFO"])
+	}
+	if discardedLimited != 3 || writtenLimited != 3 {
+		t.Errorf("RateLimited() = %d with io.Discard and %d otherwise, want 3", discardedLimited, writtenLimited)
+	}
+}
+
+func TestSetLogLevelWhileLogging(t *testing.T) {
+	l := log.NewLog()
+	l.SetOutput(io.Discard)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			if i%2 == 0 {
+				l.SetLogLevel(log.LevelError)
+			} else {
+				l.SetMinLevel(log.LevelDebug)
+			}
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		l.Info("entry %d", i)
+		l.Enabled(log.LevelDebug)
+	}
+	<-done
+	if !l.Enabled(log.LevelDebug) {
+		t.Error("Expected the last level set to be in effect")
+	}
+}
+
+func TestTrace(t *testing.T) {
+	tests := []struct {
+		level   log.Level
+		written bool
+	}{
+		{log.LevelAll, false},
+		{log.LevelAll | log.LevelTrace, true},
+		{log.LevelTrace, true},
+		{log.LevelDebug, false},
+	}
+	for _, tt := range tests {
+		var buff bytes.Buffer
+		l := log.NewLog()
+		l.SetOutput(&buff)
+		l.SetLogLevel(tt.level)
+		if err := l.Trace("Hello %d", 42); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(buff.String(), "\tTRACE\tHello 42"); got != tt.written {
+			t.Errorf("SetLogLevel(%s): TRACE written = %t, want %t: %q", tt.level, got, tt.written, buff.String())
+		}
+	}
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.Trace("Hello")
+	if buff.Len() != 0 {
+		t.Errorf("TRACE entry written by default: %q", buff.String())
+	}
+}
+
+func TestClone(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	l.SetLogLevel(log.LevelAll ^ log.LevelDebug)
+	c := l.Clone()
+	c.SetLogLevel(log.LevelDebug)
+	c.SetFormat(log.NewLogfmtFormatter())
+	if l.Enabled(log.LevelDebug) || !l.Enabled(log.LevelInfo) {
+		t.Error("Changing the clone's level changed the original's")
+	}
+	l.Debug("original")
+	l.Info("original")
+	c.Debug("clone")
+	c.Info("clone")
+	want := "ts\tINFO\toriginal\n" +
+		"ts=ts level=DEBUG msg=clone\n"
+	if got := buff.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCloneState(t *testing.T) {
+	var buff syncBuffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetRateLimit(1)
+	l.SetSampler(log.NewRepeatSampler(1, 0, time.Hour))
+	l.SetAsync(10)
+	l.Info("original")
+	l.Info("original")
+	c := l.Clone()
+	c.Info("clone")
+	c.SetAsync(0)
+	l.Info("original")
+	l.Close()
+	if got := l.Counts()["INFO"]; got != 1 {
+		t.Errorf("original counted %d INFO entries, want 1", got)
+	}
+	if got := c.Counts()["INFO"]; got != 1 {
+		t.Errorf("clone counted %d INFO entries, want 1", got)
+	}
+	if l.Sampled() != 2 || l.RateLimited() != 0 || c.Sampled() != 0 {
+		t.Errorf("Sampled() = %d and %d, want 2 and 0", l.Sampled(), c.Sampled())
+	}
+	if got := strings.Count(buff.String(), "\tINFO\t"); got != 2 {
+		t.Errorf("Wrote %d entries, want 2: %s", got, buff.String())
+	}
+}
+
+func TestSetTimeFormat(t *testing.T) {
+	tests := []struct {
+		layout string
+		loc    *time.Location
+		want   string
+	}{
+		{"MST -0700", time.FixedZone("IST", 5*3600+1800), "IST +0530"},
+		{"Z07:00", nil, "Z"},
+	}
+	for _, tt := range tests {
+		var buff bytes.Buffer
+		l := log.NewLog()
+		l.SetOutput(&buff)
+		l.SetTimeFormat(tt.layout, tt.loc)
+		l.Info("Hello")
+		if got := buff.String(); !strings.HasPrefix(got, tt.want+"\tINFO\t") {
+			t.Errorf("SetTimeFormat(%q, %v): got %q, want prefix %q", tt.layout, tt.loc, got, tt.want)
+		}
+	}
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimeFormat("", nil)
+	l.Info("Hello")
+	ts, _, _ := strings.Cut(buff.String(), "\t")
+	if _, err := time.Parse(time.RFC3339Nano, ts); err != nil || !strings.HasSuffix(ts, "Z") {
+		t.Errorf("Expected a UTC RFC3339Nano timestamp, got %q", ts)
+	}
+}
+
+func TestSetPrefix(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	l.Info("no prefix")
+	l.SetPrefix("[auth] ")
+	l.Info("Hello")
+	l.Error("denied %s", "bob")
+	want := "ts\tINFO\tno prefix\nts\tINFO\t[auth] Hello\nts\tERROR\t[auth] denied bob\n"
+	if got := buff.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	buff.Reset()
+	l.SetFormat(&log.JSONFormatter{})
+	l.Warning("slow")
+	if got := buff.String(); !strings.Contains(got, `"msg":"[auth] slow"`) {
+		t.Errorf("got %q, want the prefix in the JSON message", got)
+	}
+}
+
+func TestSetTerminator(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	l.Info("lf")
+	l.SetTerminator("\r\n")
+	l.Info("crlf")
+	l.Info("two\nlines")
+	l.SetTerminator("")
+	l.Info("none")
+	want := "ts\tINFO\tlf\nts\tINFO\tcrlf\r\nts\tINFO\ttwo\nlines\r\nts\tINFO\tnone"
+	if got := buff.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestErrorIf(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	if err := l.ErrorIf(nil, "closing %s", "a"); err != nil || buff.Len() != 0 {
+		t.Errorf("ErrorIf(nil) returned %v and wrote %q", err, buff.String())
+	}
+	failed := errors.New("disk full")
+	if err := l.ErrorIf(failed, "closing %s", "a"); err != failed {
+		t.Errorf("ErrorIf returned %v, want %v", err, failed)
+	}
+	if err := l.WarnIf(failed, "retrying"); err != failed {
+		t.Errorf("WarnIf returned %v, want %v", err, failed)
+	}
+	want := "ts\tERROR\tclosing a: disk full\nts\tWARNING\tretrying: disk full\n"
+	if got := buff.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSetErrorHandler(t *testing.T) {
+	bad := &failingWriter{err: errors.New("disk full")}
+	var mu sync.Mutex
+	var handled []error
+	l := log.NewLog()
+	l.SetOutput(bad)
+	l.SetErrorHandler(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, err)
+	})
+	if err := l.Info("Hello"); !errors.Is(err, bad.err) {
+		t.Errorf("Expected write error, got %v", err)
+	}
+	l.SetAsync(10)
+	l.Info("Hello")
+	l.Close()
+	mu.Lock()
+	defer mu.Unlock()
+	if len(handled) != 2 {
+		t.Fatalf("Handler called %d times, want 2", len(handled))
+	}
+	for _, err := range handled {
+		if !errors.Is(err, bad.err) {
+			t.Errorf("Handler called with %v", err)
+		}
+	}
+}
+
+func TestFlushBuffered(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(bufio.NewWriter(&buff))
+	l.Info("Hello")
+	if buff.Len() != 0 {
+		t.Fatalf("Entry written before Flush: %q", buff.String())
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buff.String(), "\tINFO\tHello\n") {
+		t.Errorf("Entry not written by Flush: %q", buff.String())
+	}
+	buff.Reset()
+	l.Info("Goodbye")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buff.String(), "\tINFO\tGoodbye\n") {
+		t.Errorf("Entry not written by Close: %q", buff.String())
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	levels := []log.Level{
+		log.LevelAll, log.LevelNone, log.LevelAll ^ log.LevelDebug,
+		log.LevelInfo | log.LevelError, log.LevelCustom,
+	}
+	for _, ll := range levels {
+		var buff bytes.Buffer
+		l := log.NewLog()
+		l.SetOutput(&buff)
+		l.SetLogLevel(ll)
+		for _, tt := range []struct {
+			level log.Level
+			write func(string, ...interface{}) error
+		}{
+			{log.LevelDebug, l.Debug},
+			{log.LevelInfo, l.Info},
+			{log.LevelWarning, l.Warning},
+			{log.LevelError, l.Error},
+			{log.LevelCustom, func(f string, args ...interface{}) error { return l.Custom("TEST", f, args...) }},
+		} {
+			buff.Reset()
+			tt.write("Hello")
+			if written := buff.Len() > 0; written != l.Enabled(tt.level) {
+				t.Errorf("Level %s: Enabled(%s) = %v, but written = %v", ll, tt.level, l.Enabled(tt.level), written)
+			}
+		}
+	}
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	msg := strings.Repeat("Hello world ", 20)
+	var wg sync.WaitGroup
+	for g := 0; g < 50; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				l.Error("%02d %s", g, msg)
+			}
+		}(g)
+	}
+	wg.Wait()
+	lines := strings.Split(strings.TrimSuffix(buff.String(), "\n"), "\n")
+	if len(lines) != 50*100 {
+		t.Errorf("Got %d lines, want %d", len(lines), 50*100)
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "ts\tERROR\t") || !strings.HasSuffix(line, msg) || len(line) != len("ts\tERROR\t00 ")+len(msg) {
+			t.Fatalf("Torn line: %q", line)
+		}
+	}
+}
+
+func TestFlushEachEntry(t *testing.T) {
+	var buff bytes.Buffer
+	bw := bufio.NewWriterSize(&buff, 1<<20)
+	l := log.NewLog()
+	l.SetOutput(bw)
+	l.SetFormat(&log.JSONFormatter{})
+	l.SetFlushEachEntry(true)
+	l.Info("Hello")
+	if bw.Buffered() != 0 || !strings.HasSuffix(buff.String(), "}\n") {
+		t.Fatalf("Entry not flushed: %q", buff.String())
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 20; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				l.WithFields(map[string]interface{}{"g": g, "text": "line\nbreak"}).Error("%d\n%d", g, n)
+			}
+		}(g)
+	}
+	wg.Wait()
+	if bw.Buffered() != 0 {
+		t.Errorf("%d bytes left buffered", bw.Buffered())
+	}
+	lines := strings.Split(strings.TrimSuffix(buff.String(), "\n"), "\n")
+	if len(lines) != 1+20*100 {
+		t.Errorf("Got %d lines, want %d", len(lines), 1+20*100)
+	}
+	for _, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Fatalf("Invalid JSON line: %q", line)
+		}
+	}
+}
+
+func TestNewFileLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := log.NewFileLog(path)
+	if err != nil {
+		t.Fatalf("NewFileLog failed: %s", err)
+	}
+	l.Info("durable")
+	if err := l.Sync(); err != nil {
+		t.Errorf("Sync failed: %s", err)
+	}
+	if b, err := os.ReadFile(path); err != nil || !strings.Contains(string(b), "\tINFO\tdurable\n") {
+		t.Errorf("got %q (%v), want the entry", b, err)
+	}
+	if err := l.Close(); err != nil {
+		t.Error(err)
+	}
+	if _, err := log.NewFileLog(filepath.Join(path, "missing", "app.log")); err == nil {
+		t.Error("Expected an error for an invalid path")
+	}
+
+	var buff bytes.Buffer
+	l = log.NewLog()
+	l.SetOutput(&buff)
+	l.Info("buffered")
+	if err := l.Sync(); err != nil {
+		t.Errorf("Sync of a buffer returned %v", err)
+	}
+	if err := log.NewLog().Sync(); err != nil {
+		t.Errorf("Sync of os.Stderr returned %v", err)
+	}
+}
+
+func TestMustFileLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l := log.MustFileLog(path)
+	l.Info("opened")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(path); err != nil || !strings.Contains(string(b), "\tINFO\topened\n") {
+		t.Errorf("got %q (%v), want the entry", b, err)
+	}
+	bad := filepath.Join(path, "missing", "app.log")
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), bad) {
+			t.Errorf("Expected a panic naming the path, got %v", r)
+		}
+	}()
+	log.MustFileLog(bad)
+}
+
+func TestReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	l := log.NewLog()
+	if err := l.Reopen(); err != log.ErrNotReopenable {
+		t.Errorf("Expected ErrNotReopenable, got %v", err)
+	}
+	if err := l.SetOutputFile(path); err != nil {
+		t.Fatalf("Could not set output file: %s", err)
+	}
+	l.Info("before")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("Could not rename log file: %s", err)
+	}
+	l.Info("moved")
+	if err := l.Reopen(); err != nil {
+		t.Fatalf("Reopen failed: %s", err)
+	}
+	l.Info("after")
+	old, err := os.ReadFile(path + ".1")
+	if err != nil {
+		t.Fatalf("Could not read moved file: %s", err)
+	}
+	if !strings.Contains(string(old), "before") || !strings.Contains(string(old), "moved") || strings.Contains(string(old), "after") {
+		t.Errorf("Bad moved file: %s", old)
+	}
+	cur, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Could not read reopened file: %s", err)
+	}
+	if !strings.Contains(string(cur), "after") || strings.Contains(string(cur), "before") {
+		t.Errorf("Bad reopened file: %s", cur)
+	}
+	l.Close()
+	l.SetOutput(io.Discard)
+	if err := l.Reopen(); err != log.ErrNotReopenable {
+		t.Errorf("Expected ErrNotReopenable after SetOutput, got %v", err)
+	}
+}
+
+func TestReopenAsync(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l := log.NewLog()
+	if err := l.SetOutputFile(path); err != nil {
+		t.Fatalf("Could not set output file: %s", err)
+	}
+	var errs int64
+	l.SetErrorHandler(func(error) { atomic.AddInt64(&errs, 1) })
+	l.SetAsync(5000)
+	for n := 0; n < 5000; n++ {
+		l.Info("entry")
+	}
+	if err := l.Reopen(); err != nil {
+		t.Fatalf("Reopen failed: %s", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if errs := atomic.LoadInt64(&errs); errs != 0 {
+		t.Errorf("Got %d write errors", errs)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(b), "\tINFO\tentry\n"); n != 5000 {
+		t.Errorf("Got %d entries, want 5000", n)
+	}
+}
+
+func TestCrashFile(t *testing.T) {
+	var buff bytes.Buffer
+	crash := filepath.Join(t.TempDir(), "crash.log")
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetCrashFile(crash)
+	l.Error("not a crash")
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Panic didn't panic")
+			}
+		}()
+		l.Panic("Hello %s", "crash")
+	}()
+	b, err := os.ReadFile(crash)
+	if err != nil {
+		t.Fatalf("Could not read crash file: %s", err)
+	}
+	c := string(b)
+	if !strings.Contains(c, "PANIC\tHello crash") {
+		t.Errorf("Crash file missing PANIC entry: %s", c)
+	}
+	if strings.Count(c, "log_test.TestCrashFile\n") != 1 || strings.Contains(c, " [running]:") || strings.Contains(c, "log.(*Log)") {
+		t.Errorf("Crash file should have the entry's stack trace only: %s", c)
+	}
+	if strings.Contains(c, "not a crash") {
+		t.Errorf("Crash file contains non-crash entry: %s", c)
+	}
+	if !strings.Contains(buff.String(), "PANIC\tHello crash") {
+		t.Errorf("PANIC entry missing from normal output: %s", buff.String())
+	}
+}
+
+func TestCrashFileStack(t *testing.T) {
+	crash := filepath.Join(t.TempDir(), "crash.log")
+	l := log.NewLog()
+	l.SetOutput(io.Discard)
+	l.SetCrashFile(crash)
+	l.SetFatalAsError(false)
+	l.SetStackTraceLevel(log.LevelPanic)
+	l.SetExitFunc(func(int) {})
+	l.Fatal("Hello fatal")
+	b, err := os.ReadFile(crash)
+	if err != nil {
+		t.Fatalf("Could not read crash file: %s", err)
+	}
+	if c := string(b); !strings.Contains(c, "FATAL\tHello fatal\n") || strings.Count(c, " [running]:") != 1 {
+		t.Errorf("Crash file should have the current stack trace: %s", c)
+	}
+}
+
+func TestCrashFileRecoveredStack(t *testing.T) {
+	crash := filepath.Join(t.TempDir(), "crash.log")
+	l := log.NewLog()
+	l.SetOutput(io.Discard)
+	l.SetCrashFile(crash)
+	l.SetStackTraceLevel(log.LevelNone)
+	func() {
+		defer func() { recover() }()
+		defer l.RecoverAndLog()
+		panic("boom")
+	}()
+	b, err := os.ReadFile(crash)
+	if err != nil {
+		t.Fatalf("Could not read crash file: %s", err)
+	}
+	if c := string(b); !strings.Contains(c, "\tstack=") || strings.Contains(c, " [running]:") {
+		t.Errorf("Crash file should only have the recovered stack trace: %s", c)
+	}
+}
+
+func TestCrashFileCallerStack(t *testing.T) {
+	crash := filepath.Join(t.TempDir(), "crash.log")
+	l := log.NewLog()
+	l.SetOutput(io.Discard)
+	l.SetCrashFile(crash)
+	func() {
+		defer func() { recover() }()
+		l.WithFields(map[string]interface{}{"stack": "not a stack"}).Panic("boom")
+	}()
+	b, err := os.ReadFile(crash)
+	if err != nil {
+		t.Fatalf("Could not read crash file: %s", err)
+	}
+	if c := string(b); !strings.Contains(c, "stack=not a stack\n") || strings.Count(c, " [running]:") != 1 {
+		t.Errorf("Crash file should have the current stack trace: %s", c)
+	}
+}
+
+func TestExitFunc(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	code := -1
+	l.SetExitFunc(func(c int) {
+		if !strings.Contains(buff.String(), "FATAL\tHello fatal") {
+			t.Errorf("FATAL entry wasn't written before exit: %s", buff.String())
+		}
+		code = c
+	})
+	l.Fatal("Hello %s", "fatal")
+	if code != 1 {
+		t.Errorf("Bad exit code: %d", code)
+	}
+}
+
+func TestExitFuncNil(t *testing.T) {
+	if os.Getenv("LOG_TEST_EXIT") == "1" {
+		l := log.NewLog()
+		l.SetExitFunc(func(int) {})
+		l.SetExitFunc(nil)
+		l.Fatal("exiting")
+		return
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestExitFuncNil$")
+	cmd.Env = append(os.Environ(), "LOG_TEST_EXIT=1")
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		t.Errorf("Expected exit code 1 after SetExitFunc(nil), got %v: %s", err, out)
+	}
+}
+
+func TestFatalAsError(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetFatalAsError(true)
+	if err := l.Fatal("Hello %s", "fatal"); err != nil {
+		t.Errorf("Fatal returned error: %s", err)
+	}
+	if err := l.Panic("Hello %s", "panic"); err != nil {
+		t.Errorf("Panic returned error: %s", err)
+	}
+	b := buff.String()
+	if !strings.Contains(b, "FATAL\tHello fatal") {
+		t.Errorf("FATAL wasn't written: %s", b)
+	}
+	if !strings.Contains(b, "PANIC\tHello panic") {
+		t.Errorf("PANIC wasn't written: %s", b)
+	}
+}
+
+type panickingStringer struct{}
+
+func (panickingStringer) String() string {
+	panic("boom")
+}
+
+// countingStringer counts the calls to its String method.
+type countingStringer struct {
+	calls int
+}
+
+func (s *countingStringer) String() string {
+	s.calls++
+	return "counted"
+}
+
+func TestPanicFormatsOnce(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	s := &countingStringer{}
+	var recovered interface{}
+	func() {
+		defer func() { recovered = recover() }()
+		l.Panic("Hello %s", s)
+	}()
+	if recovered != "Hello counted" {
+		t.Errorf("Bad panic value: %v", recovered)
+	}
+	if s.calls != 1 {
+		t.Errorf("String called %d times, want 1", s.calls)
+	}
+	if !strings.Contains(buff.String(), "\tPANIC\tHello counted") {
+		t.Errorf("PANIC entry missing: %s", buff.String())
+	}
+}
+
+func TestPanickingStringer(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	if err := l.Info("Hello %v", panickingStringer{}); err != nil {
+		t.Errorf("Info returned error: %s", err)
+	}
+	if !strings.Contains(buff.String(), "Hello %!v(PANIC=String method: boom)") {
+		t.Errorf("Missing panic placeholder: %s", buff.String())
+	}
+}
+
 func BenchmarkLog_basic(b *testing.B) {
 	err := log.SetOutputFile(os.DevNull)
 	if err != nil {
@@ -98,6 +934,17 @@ func BenchmarkLog_basic(b *testing.B) {
 	}
 }
 
+// BenchmarkLog_pooledEntry measures an entry assembled in a pooled buffer and written with a single
+// Write, with no fields or caller.
+func BenchmarkLog_pooledEntry(b *testing.B) {
+	l := log.NewLog()
+	l.SetOutput(nopWriter{})
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		l.Error("Hello world")
+	}
+}
+
 func BenchmarkLog_struct(b *testing.B) {
 	err := log.SetOutputFile(os.DevNull)
 	if err != nil {
@@ -120,6 +967,94 @@ func BenchmarkLog_formatting(b *testing.B) {
 	}
 }
 
+func TestFuncVariants(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetLogLevel(log.LevelAll ^ log.LevelDebug)
+	called := false
+	l.DebugFunc(func() string { called = true; return "debug" })
+	if called {
+		t.Error("DebugFunc called f with DEBUG disabled")
+	}
+	l.InfoFunc(func() string { return "info 100%" })
+	l.WarningFunc(func() string { return "warning" })
+	l.ErrorFunc(func() string { return "error" })
+	b := buff.String()
+	for _, want := range []string{"INFO\tinfo 100%\n", "WARNING\twarning\n", "ERROR\terror\n"} {
+		if !strings.Contains(b, want) {
+			t.Errorf("Missing %q: %s", want, b)
+		}
+	}
+}
+
+func TestLnVariants(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	l.Errorln("100%")
+	l.Infoln("progress", 50, "%d")
+	l.Warningln()
+	want := "ts\tERROR\t100%\n" +
+		"ts\tINFO\tprogress 50 %d\n" +
+		"ts\tWARNING\t\n"
+	if got := buff.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// nopWriter discards what is written to it. Benchmarks of the write path use it rather than io.Discard,
+// whose entries aren't formatted.
+type nopWriter struct{}
+
+func (nopWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func BenchmarkLog_discard(b *testing.B) {
+	l := log.NewLog()
+	l.SetOutput(io.Discard)
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		l.Info("%s %d %v", "Hello", 1234, struct{ s string }{"World"})
+	}
+}
+
+func BenchmarkLog_nopWriter(b *testing.B) {
+	l := log.NewLog()
+	l.SetOutput(nopWriter{})
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		l.Info("%s %d %v", "Hello", 1234, struct{ s string }{"World"})
+	}
+}
+
+// expensive simulates building a costly log message.
+func expensive() string {
+	return fmt.Sprintf("%v", [][]int{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}})
+}
+
+func BenchmarkLog_disabledDebug(b *testing.B) {
+	l := log.NewLog()
+	l.SetOutput(io.Discard)
+	l.SetLogLevel(log.LevelAll ^ log.LevelDebug)
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		l.Debug("%s", expensive())
+	}
+}
+
+func BenchmarkLog_disabledDebugFunc(b *testing.B) {
+	l := log.NewLog()
+	l.SetOutput(io.Discard)
+	l.SetLogLevel(log.LevelAll ^ log.LevelDebug)
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		l.DebugFunc(expensive)
+	}
+}
+
 // Example of using the global log.
 func Example() {
 	log.SetOutput(os.Stdout)
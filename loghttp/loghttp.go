@@ -0,0 +1,98 @@
+// Package loghttp logs HTTP requests to a log.Log. It is kept out of package log so that programs that
+// don't serve HTTP don't link net/http.
+package loghttp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/Syncbak-Git/log"
+)
+
+// RequestIDHeader is the header that Middleware reads an incoming request ID from, and writes the
+// request ID to in the response.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey is the context key for the request ID set by Middleware.
+type requestIDKey struct{}
+
+// Middleware returns a handler that calls next and then writes an entry for the request to l, with its
+// method, path, response status and duration as fields. Requests answered with a 5xx status are
+// written as ERROR entries and the others as INFO entries. Each request is given an ID, taken from its
+// X-Request-ID header or generated, which is written as the request_id field, set as the X-Request-ID
+// header of the response, and added to the request's context: RequestID returns it, and the XXXCtx
+// methods of log.Log write it as the request_id field of entries logged with the context.
+func Middleware(l *log.Log, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		ctx = log.WithContextField(ctx, "request_id", id)
+		w.Header().Set(RequestIDHeader, id)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r.WithContext(ctx))
+		write := l.InfoKV
+		if rec.status >= 500 {
+			write = l.ErrorKV
+		}
+		write("handled request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration", time.Since(start).String(),
+			"request_id", id,
+		)
+	})
+}
+
+// RequestID returns the request ID added to ctx by Middleware, or "" if there is none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRequestID returns a random 16 character hex request ID.
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// statusRecorder records the status written to a ResponseWriter.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = status, true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(p)
+}
+
+// Unwrap returns the underlying ResponseWriter, so that http.ResponseController can reach it.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Flush implements http.Flusher for handlers that stream their response, if the underlying
+// ResponseWriter supports it.
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		r.wroteHeader = true
+		f.Flush()
+	}
+}
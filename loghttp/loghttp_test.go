@@ -0,0 +1,49 @@
+package loghttp_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/Syncbak-Git/log"
+	"github.com/Syncbak-Git/log/loghttp"
+)
+
+func TestMiddleware(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	var gotID string
+	h := loghttp.Middleware(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID = loghttp.RequestID(r.Context())
+		l.InfoCtx(r.Context(), "handling")
+		if r.URL.Path == "/fail" {
+			http.Error(w, "broken", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/ok", nil))
+	if gotID == "" || rec.Header().Get(loghttp.RequestIDHeader) != gotID {
+		t.Errorf("got request ID %q and header %q", gotID, rec.Header().Get(loghttp.RequestIDHeader))
+	}
+	want := regexp.MustCompile(`^ts\tINFO\thandling\trequest_id=[0-9a-f]{16}\n` +
+		`ts\tINFO\thandled request\tduration=\S+ method=GET path=/ok request_id=[0-9a-f]{16} status=200\n$`)
+	if !want.MatchString(buff.String()) {
+		t.Errorf("got %q, want a match for %s", buff.String(), want)
+	}
+
+	buff.Reset()
+	req := httptest.NewRequest("POST", "/fail", nil)
+	req.Header.Set(loghttp.RequestIDHeader, "abc")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	want = regexp.MustCompile(`\tERROR\thandled request\tduration=\S+ method=POST path=/fail request_id=abc status=500\n$`)
+	if !want.MatchString(buff.String()) {
+		t.Errorf("got %q, want a match for %s", buff.String(), want)
+	}
+}
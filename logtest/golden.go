@@ -0,0 +1,54 @@
+package logtest
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+	"testing"
+)
+
+// GoldenWriter returns a Writer that accumulates log output for a golden-file test. When the test
+// and its subtests finish, the accumulated output is compared against the contents of path and any
+// mismatch is reported via t.Errorf. If update is true, path is rewritten with the output instead.
+// Use the Log's SetTimestamp to make the output deterministic.
+func GoldenWriter(t testing.TB, path string, update bool) io.Writer {
+	t.Helper()
+	w := &goldenWriter{}
+	t.Cleanup(func() {
+		got := w.bytes()
+		if update {
+			if err := os.WriteFile(path, got, 0644); err != nil {
+				t.Errorf("logtest: could not update golden file: %s", err)
+			}
+			return
+		}
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("logtest: could not read golden file: %s", err)
+			return
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("logtest: output does not match golden file %s\n--- got:\n%s--- want:\n%s", path, got, want)
+		}
+	})
+	return w
+}
+
+// goldenWriter is a bytes.Buffer that is safe for concurrent use.
+type goldenWriter struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *goldenWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *goldenWriter) bytes() []byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Bytes()
+}
@@ -0,0 +1,69 @@
+package logtest_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Syncbak-Git/log"
+	"github.com/Syncbak-Git/log/logtest"
+)
+
+// fakeTB records the Cleanup and Errorf calls made by GoldenWriter.
+type fakeTB struct {
+	testing.TB
+	cleanups []func()
+	errors   []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Cleanup(c func()) {
+	f.cleanups = append(f.cleanups, c)
+}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) finish() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+func TestGoldenWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden.txt")
+	logTo := func(tb testing.TB, update bool, msg string) {
+		l := log.NewLog()
+		l.SetOutput(logtest.GoldenWriter(tb, path, update))
+		l.SetTimestamp(func() string { return "2006-01-02T15:04:05.999999999Z" })
+		l.Info("%s", msg)
+	}
+	// create the golden file
+	ft := &fakeTB{TB: t}
+	logTo(ft, true, "Hello world")
+	ft.finish()
+	if len(ft.errors) != 0 {
+		t.Fatalf("Update failed: %v", ft.errors)
+	}
+	if b, err := os.ReadFile(path); err != nil || string(b) != "2006-01-02T15:04:05.999999999Z\tINFO\tHello world\n" {
+		t.Fatalf("Bad golden file: %q (%v)", b, err)
+	}
+	// matching output
+	ft = &fakeTB{TB: t}
+	logTo(ft, false, "Hello world")
+	ft.finish()
+	if len(ft.errors) != 0 {
+		t.Errorf("Matching output reported errors: %v", ft.errors)
+	}
+	// mismatched output
+	ft = &fakeTB{TB: t}
+	logTo(ft, false, "Goodbye world")
+	ft.finish()
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "Goodbye world") {
+		t.Errorf("Mismatch wasn't reported: %v", ft.errors)
+	}
+}
@@ -0,0 +1,90 @@
+// Package logtest captures the entries written by a log.Log as structured records, so that tests can
+// check them without searching the formatted output.
+package logtest
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Syncbak-Git/log"
+)
+
+// Entry is a captured log entry.
+type Entry struct {
+	Level   string // the level name, eg. "ERROR", or the custom level
+	Message string
+	Time    time.Time // the zero Time if the timestamp isn't in RFC3339 format
+	Fields  map[string]interface{}
+}
+
+// Capture records the entries written by a Log. It is a log.Formatter, so it stops recording if the
+// Log's format is changed with SetFormat. It is safe for concurrent use.
+type Capture struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// NewTestLog returns a Log with all levels enabled that records its entries in the returned Capture
+// and discards the formatted output.
+func NewTestLog() (*log.Log, *Capture) {
+	c := &Capture{}
+	l := log.NewLog()
+	l.SetOutput(discard{})
+	l.SetFormat(c)
+	return l, c
+}
+
+// discard drops the formatted output. io.Discard isn't used, since a Log doesn't format the entries
+// it would write to io.Discard.
+type discard struct{}
+
+func (discard) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// Format records the entry, and returns its message as the formatted entry.
+func (c *Capture) Format(timestamp, level, message string, fields map[string]interface{}) ([]byte, error) {
+	e := Entry{Level: level, Message: message, Fields: make(map[string]interface{}, len(fields))}
+	e.Time, _ = time.Parse(time.RFC3339Nano, timestamp)
+	for k, v := range fields {
+		e.Fields[k] = v
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, e)
+	return []byte(message), nil
+}
+
+// Entries returns a copy of the entries recorded so far, in the order they were written.
+func (c *Capture) Entries() []Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Entry(nil), c.entries...)
+}
+
+// Reset forgets the recorded entries.
+func (c *Capture) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = nil
+}
+
+// Contains reports whether an entry at level has a message containing substr.
+func (c *Capture) Contains(level, substr string) bool {
+	for _, e := range c.Entries() {
+		if e.Level == level && strings.Contains(e.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// AssertContains fails t unless an entry at level has a message containing substr.
+func (c *Capture) AssertContains(t testing.TB, level, substr string) {
+	t.Helper()
+	if !c.Contains(level, substr) {
+		t.Errorf("logtest: no %s entry containing %q in %d entries", level, substr, len(c.Entries()))
+	}
+}
@@ -0,0 +1,28 @@
+package logtest_test
+
+import (
+	"testing"
+
+	"github.com/Syncbak-Git/log/logtest"
+)
+
+func TestCapture(t *testing.T) {
+	l, c := logtest.NewTestLog()
+	l.Info("starting")
+	l.With("user", "bob").Error("login failed for %s", "bob")
+	entries := c.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Captured %d entries, want 2", len(entries))
+	}
+	if e := entries[1]; e.Level != "ERROR" || e.Message != "login failed for bob" || e.Fields["user"] != "bob" || e.Time.IsZero() {
+		t.Errorf("Unexpected entry %+v", e)
+	}
+	c.AssertContains(t, "INFO", "start")
+	if c.Contains("ERROR", "starting") {
+		t.Error("Contains matched an entry at another level")
+	}
+	c.Reset()
+	if len(c.Entries()) != 0 {
+		t.Error("Reset didn't forget the entries")
+	}
+}
@@ -0,0 +1,174 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"syscall"
+)
+
+// multiOutput writes each entry to several outputs. See AddOutput.
+type multiOutput []io.Writer
+
+func (m multiOutput) Write(p []byte) (int, error) {
+	if err := m.writeLevel(LevelNone, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (m multiOutput) writeLevel(level Level, p []byte) error {
+	var errs []error
+	for _, w := range m {
+		if err := writeOutput(w, level, p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiOutput) Close() error {
+	var errs []error
+	for _, w := range m {
+		if c, ok := w.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiOutput) configure(c rotateConfig) {
+	for _, w := range m {
+		configureOutput(w, c)
+	}
+}
+
+// configureOutput passes c to w, if it uses it.
+func configureOutput(w io.Writer, c rotateConfig) {
+	if rc, ok := w.(rotateConfigurer); ok {
+		rc.configure(c)
+	}
+}
+
+// levelOutput routes the entries of the levels in mask to w. See SetLevelOutput.
+type levelOutput struct {
+	mask Level
+	w    io.Writer
+}
+
+// setLevelOutput returns a copy of outputs with the levels in mask routed to w, or to the default
+// output if w is nil.
+func setLevelOutput(outputs []levelOutput, mask Level, w io.Writer) []levelOutput {
+	var routed []levelOutput
+	for _, o := range outputs {
+		if o.mask &^= mask; o.mask != 0 {
+			routed = append(routed, o)
+		}
+	}
+	if w != nil && mask != LevelNone {
+		routed = append(routed, levelOutput{mask: mask, w: w})
+	}
+	return routed
+}
+
+// flusher is implemented by outputs that buffer, such as *bufio.Writer.
+type flusher interface {
+	Flush() error
+}
+
+func (m multiOutput) Flush() error {
+	return flushWriters(m...)
+}
+
+// syncer is implemented by outputs that can commit what has been written to stable storage, such
+// as *os.File.
+type syncer interface {
+	Sync() error
+}
+
+func (m multiOutput) Sync() error {
+	return syncWriters(m...)
+}
+
+// syncWriters syncs each writer in ws that is a syncer, returning the joined errors. EINVAL is ignored,
+// since it is returned for files that can't be synced, such as os.Stderr connected to a pipe or terminal.
+func syncWriters(ws ...io.Writer) error {
+	var errs []error
+	for _, w := range ws {
+		if s, ok := w.(syncer); ok {
+			if err := s.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// flushWriters flushes each writer in ws that is a flusher, returning the joined errors.
+func flushWriters(ws ...io.Writer) error {
+	var errs []error
+	for _, w := range ws {
+		if f, ok := w.(flusher); ok {
+			if err := f.Flush(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closeWriters closes each distinct writer in ws that is an io.Closer, returning the joined errors.
+func closeWriters(ws ...io.Writer) error {
+	var errs []error
+	var closed []io.Writer
+	for _, w := range flattenWriters(ws) {
+		c, ok := w.(io.Closer)
+		if !ok || containsWriter(closed, w) {
+			continue
+		}
+		closed = append(closed, w)
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// flattenWriters returns ws with each multiOutput replaced by the writers it combines, so that a writer
+// shared by several outputs can be recognized.
+func flattenWriters(ws []io.Writer) []io.Writer {
+	var flat []io.Writer
+	for _, w := range ws {
+		if m, ok := w.(multiOutput); ok {
+			flat = append(flat, flattenWriters(m)...)
+		} else {
+			flat = append(flat, w)
+		}
+	}
+	return flat
+}
+
+// containsWriter reports whether w is in ws. Writers of uncomparable types, such as multiOutput, are
+// never considered equal.
+func containsWriter(ws []io.Writer, w io.Writer) bool {
+	if !reflect.TypeOf(w).Comparable() {
+		return false
+	}
+	for _, o := range ws {
+		if reflect.TypeOf(o) == reflect.TypeOf(w) && o == w {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiOutput) describe() string {
+	ds := make([]string, len(m))
+	for i, w := range m {
+		ds[i] = describeOutput(w)
+	}
+	return strings.Join(ds, ", ")
+}
@@ -0,0 +1,189 @@
+package log_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Syncbak-Git/log"
+)
+
+// failingWriter fails every Write and Close with err.
+type failingWriter struct {
+	err    error
+	closed int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+func (w *failingWriter) Close() error {
+	w.closed++
+	return w.err
+}
+
+// closeRecorder is a bytes.Buffer that records whether it was closed.
+type closeRecorder struct {
+	bytes.Buffer
+	closed int
+}
+
+func (w *closeRecorder) Close() error {
+	w.closed++
+	return nil
+}
+
+func TestAddOutput(t *testing.T) {
+	var first closeRecorder
+	var second bytes.Buffer
+	bad := &failingWriter{err: errors.New("disk full")}
+	l := log.NewLog()
+	l.SetOutput(&first)
+	l.AddOutput(bad)
+	l.AddOutput(&second)
+	err := l.Info("Hello")
+	if !errors.Is(err, bad.err) {
+		t.Errorf("Expected write error, got %v", err)
+	}
+	for _, b := range []string{first.String(), second.String()} {
+		if !strings.Contains(b, "\tINFO\tHello\n") {
+			t.Errorf("Healthy writer missed the entry: %q", b)
+		}
+	}
+	err = l.Close()
+	if !errors.Is(err, bad.err) {
+		t.Errorf("Expected close error, got %v", err)
+	}
+	if first.closed != 1 || bad.closed != 1 {
+		t.Errorf("Writers weren't closed: %d, %d", first.closed, bad.closed)
+	}
+	l.SetOutput(&second)
+	second.Reset()
+	if err := l.Info("Hello"); err != nil || second.Len() == 0 {
+		t.Errorf("SetOutput didn't replace the writers: %v", err)
+	}
+}
+
+func TestSetLevelOutput(t *testing.T) {
+	var def, errs, warns closeRecorder
+	l := log.NewLog()
+	l.SetOutput(&def)
+	l.SetLevelOutput(log.LevelError|log.LevelFatal|log.LevelPanic, &errs)
+	l.SetLevelOutput(log.LevelWarning, &warns)
+	l.SetLevelOutput(log.LevelWarning, nil)
+	l.SetLevelOutput(log.LevelCustom, &errs)
+	l.SetFatalAsError(true)
+	l.Info("info")
+	l.Warning("warning")
+	l.Error("error")
+	l.Fatal("fatal")
+	l.Custom("TEST", "custom")
+	for _, s := range []string{"INFO\tinfo", "WARNING\twarning"} {
+		if !strings.Contains(def.String(), s) {
+			t.Errorf("%q not written to the default output: %q", s, def.String())
+		}
+	}
+	for _, s := range []string{"ERROR\terror", "FATAL\tfatal", "TEST\tcustom"} {
+		if !strings.Contains(errs.String(), s) || strings.Contains(def.String(), s) {
+			t.Errorf("%q not written only to the level output: %q, %q", s, errs.String(), def.String())
+		}
+	}
+	if warns.Len() != 0 {
+		t.Errorf("Unexpected output after removing the level output: %q", warns.String())
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if def.closed != 1 || errs.closed != 1 || warns.closed != 0 {
+		t.Errorf("Got %d, %d and %d closes, want 1, 1 and 0", def.closed, errs.closed, warns.closed)
+	}
+}
+
+func TestSetOutputFilesByLevel(t *testing.T) {
+	dir := t.TempDir()
+	app, errs := filepath.Join(dir, "app.log"), filepath.Join(dir, "error.log")
+	l := log.NewLog()
+	l.SetOutput(io.Discard) // so that Close doesn't close os.Stderr
+	l.SetTimestamp(func() string { return "ts" })
+	err := l.SetOutputFilesByLevel(map[log.Level]string{
+		log.LevelAll: app,
+		log.LevelError | log.LevelFatal | log.LevelPanic: errs,
+		log.LevelPanic: app,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("started")
+	l.Error("failed")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close failed: %s", err)
+	}
+	for path, want := range map[string]string{
+		app:  "ts\tINFO\tstarted\nts\tERROR\tfailed\n",
+		errs: "ts\tERROR\tfailed\n",
+	} {
+		if b, err := os.ReadFile(path); err != nil || string(b) != want {
+			t.Errorf("%s: got %q (%v), want %q", filepath.Base(path), b, err, want)
+		}
+	}
+
+	var buff bytes.Buffer
+	l = log.NewLog()
+	l.SetOutput(&buff)
+	err = l.SetOutputFilesByLevel(map[log.Level]string{
+		log.LevelInfo:  filepath.Join(dir, "info.log"),
+		log.LevelError: filepath.Join(dir, "missing", "error.log"),
+	})
+	if err == nil {
+		t.Fatal("Expected an error for a file that can't be opened")
+	}
+	l.Info("unchanged")
+	if !strings.Contains(buff.String(), "unchanged") {
+		t.Errorf("Expected the output to be unchanged, got %q", buff.String())
+	}
+}
+
+func TestSetOutputFilesByLevelTwice(t *testing.T) {
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("Can't count the open files:", err)
+	}
+	dir := t.TempDir()
+	app, errs := filepath.Join(dir, "app.log"), filepath.Join(dir, "error.log")
+	l := log.NewLog()
+	l.SetOutput(io.Discard)
+	l.SetTimestamp(func() string { return "ts" })
+	if err := l.SetOutputFilesByLevel(map[log.Level]string{log.LevelAll: app, log.LevelError: errs}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := l.SetOutputFilesByLevel(map[log.Level]string{log.LevelError: errs}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// app.log is still written to and error.log was opened again by each call
+	if after, _ := os.ReadDir("/proc/self/fd"); len(after) != len(fds)+2 {
+		t.Errorf("Got %d open files, want %d", len(after), len(fds)+2)
+	}
+	l.Info("started")
+	l.Error("failed")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close failed: %s", err)
+	}
+	for path, want := range map[string]string{
+		app:  "ts\tINFO\tstarted\n",
+		errs: "ts\tERROR\tfailed\n",
+	} {
+		if b, err := os.ReadFile(path); err != nil || string(b) != want {
+			t.Errorf("%s: got %q (%v), want %q", filepath.Base(path), b, err, want)
+		}
+	}
+	if after, _ := os.ReadDir("/proc/self/fd"); len(after) != len(fds) {
+		t.Errorf("Got %d open files after Close, want %d", len(after), len(fds))
+	}
+}
@@ -0,0 +1,96 @@
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// rateLimiter is a token bucket holding up to one second's worth of entries, refilled continuously.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // entries per second, and the bucket's capacity
+	tokens float64
+	last   time.Time
+}
+
+// allow reports whether an entry may be written at now, taking a token if so.
+func (r *rateLimiter) allow(now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.last.IsZero() {
+		r.tokens = r.rate
+	} else if elapsed := now.Sub(r.last); elapsed > 0 {
+		r.tokens += elapsed.Seconds() * r.rate
+		if r.tokens > r.rate {
+			r.tokens = r.rate
+		}
+	}
+	if !now.Before(r.last) {
+		r.last = now
+	}
+	if r.tokens < 1 {
+		return false
+	}
+	r.tokens--
+	return true
+}
+
+// SetRateLimit caps the entries l writes at perSecond a second, regardless of their content, and drops
+// the rest, to protect the output from a storm of entries. The limit is a token bucket that holds a
+// second's worth of entries, so a burst of up to perSecond entries is written at once after a quiet
+// period, and after that entries are let through at the steady rate. FATAL and PANIC entries are never
+// dropped. The number of dropped entries is returned by RateLimited, and FlushRateLimited writes it as a
+// summary entry. Logs derived from l by WithFields share its limit; a Clone gets a limit of its own. A
+// perSecond of zero or less removes the limit.
+func (l *Log) SetRateLimit(perSecond int) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if perSecond <= 0 {
+		l.limiter = nil
+		return
+	}
+	l.limiter = &rateLimiter{rate: float64(perSecond)}
+}
+
+// RateLimited returns the number of entries dropped by the rate limit since the last FlushRateLimited.
+func (l *Log) RateLimited() uint64 {
+	if l == nil {
+		return 0
+	}
+	return atomic.LoadUint64(l.rateLimited)
+}
+
+// FlushRateLimited writes a WARNING entry with a "dropped" field holding the number of entries dropped
+// by the rate limit since the last call, and resets the count. It writes nothing if no entries were
+// dropped. The summary entry itself is never dropped, so it can be called periodically, eg. from a
+// time.Ticker, to record log storms.
+func (l *Log) FlushRateLimited() error {
+	if l == nil {
+		return nil
+	}
+	n := atomic.SwapUint64(l.rateLimited, 0)
+	if n == 0 || !l.Enabled(LevelWarning) {
+		return nil
+	}
+	summary := l.WithFields(map[string]interface{}{"dropped": n})
+	return summary.emit(LevelWarning, "WARNING", "dropped rate limited entries", false)
+}
+
+// allow reports whether an entry at level is within the rate limit, counting it as dropped if not.
+func (l *Log) allow(level Level) bool {
+	if level&(LevelFatal|LevelPanic) != 0 {
+		return true
+	}
+	l.mu.Lock()
+	r, now := l.limiter, l.clock
+	l.mu.Unlock()
+	if r == nil || r.allow(now()) {
+		return true
+	}
+	atomic.AddUint64(l.rateLimited, 1)
+	return false
+}
@@ -0,0 +1,60 @@
+package log_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func TestSetRateLimit(t *testing.T) {
+	var buff bytes.Buffer
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	l.SetClock(clock.Now)
+	l.SetFatalAsError(true)
+	l.SetRateLimit(10)
+	for i := 0; i < 25; i++ {
+		l.Info("entry %d", i)
+	}
+	if got := strings.Count(buff.String(), "\n"); got != 10 {
+		t.Errorf("Wrote %d entries within a second, want 10", got)
+	}
+	if got := l.RateLimited(); got != 15 {
+		t.Errorf("RateLimited() = %d, want 15", got)
+	}
+
+	clock.Advance(500 * time.Millisecond) // refills half the bucket
+	buff.Reset()
+	for i := 0; i < 10; i++ {
+		l.Info("entry %d", i)
+	}
+	l.Fatal("never dropped")
+	if got := strings.Count(buff.String(), "\tINFO\t"); got != 5 || !strings.Contains(buff.String(), "never dropped") {
+		t.Errorf("Wrote %d entries after half a second, want 5 and the FATAL entry", got)
+	}
+
+	buff.Reset()
+	if err := l.FlushRateLimited(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buff.String(), "ts\tWARNING\tdropped rate limited entries\tdropped=20\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if l.RateLimited() != 0 {
+		t.Error("Expected FlushRateLimited to reset the count")
+	}
+
+	l.SetRateLimit(0)
+	buff.Reset()
+	for i := 0; i < 25; i++ {
+		l.Info("entry %d", i)
+	}
+	if got := strings.Count(buff.String(), "\n"); got != 25 {
+		t.Errorf("Wrote %d entries without a limit, want 25", got)
+	}
+}
@@ -0,0 +1,34 @@
+package log
+
+import "fmt"
+
+// RecoverAndLog recovers a panic, writes it as a PANIC entry and panics again with the same value, so
+// the panic is recorded without changing the program's behavior. It must be deferred directly, eg.
+// defer l.RecoverAndLog(), and does nothing if there is no panic. The entry's message holds the
+// recovered value and a "stack" field holds the stack trace of the panicking goroutine. Like Panic,
+// the entry is also written to the crash file, if there is one. The panic is repeated even if PANIC
+// entries aren't enabled.
+func (l *Log) RecoverAndLog() {
+	if r := recover(); r != nil {
+		l.logRecovered(r, true)
+		panic(r)
+	}
+}
+
+// RecoverAndSwallow is like RecoverAndLog, but doesn't panic again, so that the goroutine survives
+// once the deferring function returns. It isn't written to the crash file.
+func (l *Log) RecoverAndSwallow() {
+	if r := recover(); r != nil {
+		l.logRecovered(r, false)
+	}
+}
+
+// logRecovered writes the PANIC entry for the recovered value r.
+func (l *Log) logRecovered(r interface{}, crash bool) {
+	if !l.Enabled(LevelPanic) {
+		return
+	}
+	withStack := l.WithFields(map[string]interface{}{"stack": stack()})
+	withStack.hasStack = true
+	withStack.writeMessage(LevelPanic, "PANIC", fmt.Sprintf("recovered panic: %v", r), crash)
+}
@@ -0,0 +1,59 @@
+package log_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func panicking(l *log.Log) {
+	defer l.RecoverAndLog()
+	panic("boom")
+}
+
+func TestRecoverAndLog(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	var repanicked interface{}
+	func() {
+		defer func() { repanicked = recover() }()
+		panicking(l)
+	}()
+	if repanicked != "boom" {
+		t.Errorf("Expected the panic to be repeated, got %v", repanicked)
+	}
+	out := buff.String()
+	if !strings.Contains(out, "\tPANIC\trecovered panic: boom\tstack=") {
+		t.Errorf("Missing PANIC entry: %s", out)
+	}
+	if !strings.Contains(out, "log_test.panicking") {
+		t.Errorf("Stack trace doesn't include the panicking function: %s", out)
+	}
+}
+
+func TestRecoverAndSwallow(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	returned := func() (ok bool) {
+		defer l.RecoverAndSwallow()
+		defer func() { ok = true }()
+		panic("boom")
+	}()
+	if !returned {
+		t.Error("Expected the function to return normally")
+	}
+	if !strings.Contains(buff.String(), "\tPANIC\trecovered panic: boom\tstack=") {
+		t.Errorf("Missing PANIC entry: %s", buff.String())
+	}
+	buff.Reset()
+	func() {
+		defer l.RecoverAndSwallow()
+	}()
+	if buff.Len() != 0 {
+		t.Errorf("Unexpected entry without a panic: %s", buff.String())
+	}
+}
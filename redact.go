@@ -0,0 +1,33 @@
+package log
+
+import "regexp"
+
+// redactor replaces the matches of a pattern in each message. See AddRedactor.
+type redactor struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// AddRedactor masks sensitive data in the messages of the entries written by l: every match of
+// pattern is replaced with replacement, which may refer to submatches as described for
+// regexp.Regexp.ReplaceAllString, eg. AddRedactor(regexp.MustCompile(`(password=)\S+`), "${1}***").
+// Redactors are applied in the order they were added, to the formatted message only, not to the
+// fields. Each redactor scans every message while the log's lock is held, so a log with many
+// redactors or expensive patterns is noticeably slower; a log without redactors pays nothing.
+func (l *Log) AddRedactor(pattern *regexp.Regexp, replacement string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// don't share the backing array with clones
+	l.redactors = append(l.redactors[:len(l.redactors):len(l.redactors)], redactor{pattern, replacement})
+}
+
+// redact applies the redactors to msg. l.mu must be held.
+func (l *Log) redact(msg string) string {
+	for _, r := range l.redactors {
+		msg = r.pattern.ReplaceAllString(msg, r.replacement)
+	}
+	return msg
+}
@@ -0,0 +1,25 @@
+package log_test
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func TestAddRedactor(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	l.AddRedactor(regexp.MustCompile(`(password=)\S+`), "${1}[REDACTED]")
+	l.AddRedactor(regexp.MustCompile(`\[REDACTED\]`), "***")
+	l.Info("login user=bob password=%s from 10.0.0.1", "hunter2")
+	l.Info("nothing secret here")
+	want := "ts\tINFO\tlogin user=bob password=*** from 10.0.0.1\n" +
+		"ts\tINFO\tnothing secret here\n"
+	if got := buff.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
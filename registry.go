@@ -0,0 +1,66 @@
+package log
+
+import (
+	"strings"
+	"sync"
+)
+
+// registry holds the loggers returned by GetLogger, and the levels set for them by prefix.
+var registry = struct {
+	sync.Mutex
+	loggers map[string]*Log
+	levels  map[string]Level // by name prefix, "" for all loggers
+}{loggers: make(map[string]*Log), levels: make(map[string]Level)}
+
+// GetLogger returns the logger called name, creating it if it doesn't exist yet. A new logger starts as
+// a copy of the global log's settings, with a "logger" field holding its name, and takes the level most
+// recently set for it, or its nearest parent, by SetAllLevels or SetLevelByPrefix. Names are
+// hierarchical, with the parts separated by dots, eg. "db.query" is a child of "db". Each logger can
+// then be changed independently of the others.
+func GetLogger(name string) *Log {
+	registry.Lock()
+	defer registry.Unlock()
+	if l, ok := registry.loggers[name]; ok {
+		return l
+	}
+	l := std.With("logger", name)
+	best := -1
+	for prefix, level := range registry.levels {
+		if hasNamePrefix(name, prefix) && len(prefix) > best {
+			best = len(prefix)
+			l.SetLogLevel(level)
+		}
+	}
+	registry.loggers[name] = l
+	return l
+}
+
+// SetAllLevels sets the log level of every logger returned by GetLogger, including those created later.
+// It doesn't change the global log.
+func SetAllLevels(level Level) {
+	SetLevelByPrefix("", level)
+}
+
+// SetLevelByPrefix sets the log level of the loggers returned by GetLogger that are called prefix or are
+// its children, eg. "db" and "db.query" but not "dbx", including those created later. It replaces the
+// levels set earlier for children of prefix. An empty prefix matches every logger.
+func SetLevelByPrefix(prefix string, level Level) {
+	registry.Lock()
+	defer registry.Unlock()
+	for p := range registry.levels {
+		if hasNamePrefix(p, prefix) {
+			delete(registry.levels, p)
+		}
+	}
+	registry.levels[prefix] = level
+	for name, l := range registry.loggers {
+		if hasNamePrefix(name, prefix) {
+			l.SetLogLevel(level)
+		}
+	}
+}
+
+// hasNamePrefix reports whether name is prefix, or one of its descendants.
+func hasNamePrefix(name, prefix string) bool {
+	return prefix == "" || name == prefix || strings.HasPrefix(name, prefix+".")
+}
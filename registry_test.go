@@ -0,0 +1,50 @@
+package log_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func TestGetLogger(t *testing.T) {
+	var wg sync.WaitGroup
+	loggers := make([]*log.Log, 10)
+	for i := range loggers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			loggers[i] = log.GetLogger("test.registry")
+		}(i)
+	}
+	wg.Wait()
+	for _, l := range loggers {
+		if l != loggers[0] {
+			t.Fatal("GetLogger returned different loggers for the same name")
+		}
+	}
+
+	query := log.GetLogger("testdb.query")
+	db := log.GetLogger("testdb")
+	sibling := log.GetLogger("testdbx")
+	log.SetLevelByPrefix("testdb", log.LevelDebug)
+	for _, l := range []*log.Log{db, query} {
+		if !l.Enabled(log.LevelDebug) || l.Enabled(log.LevelInfo) {
+			t.Error("SetLevelByPrefix didn't change a child's level")
+		}
+	}
+	if !sibling.Enabled(log.LevelInfo) {
+		t.Error("SetLevelByPrefix changed a sibling's level")
+	}
+	if conn := log.GetLogger("testdb.conn"); !conn.Enabled(log.LevelDebug) || conn.Enabled(log.LevelInfo) {
+		t.Error("New child didn't take the prefix's level")
+	}
+
+	log.SetAllLevels(log.LevelError)
+	for _, l := range []*log.Log{db, query, sibling, log.GetLogger("testnew")} {
+		if l.Enabled(log.LevelDebug) || !l.Enabled(log.LevelError) {
+			t.Error("SetAllLevels didn't change a logger's level")
+		}
+	}
+	log.SetAllLevels(log.LevelAll)
+}
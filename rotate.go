@@ -0,0 +1,339 @@
+package log
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotateConfig holds the Log settings that apply to rotating outputs.
+type rotateConfig struct {
+	now      func() time.Time
+	onError  func(error)
+	compress bool
+	maxAge   time.Duration
+}
+
+// rotateConfigurer is implemented by outputs that use the settings in rotateConfig.
+type rotateConfigurer interface {
+	configure(c rotateConfig)
+}
+
+// rotatingFile is a file that is rotated once it reaches a maximum size.
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxBytes   int64
+	maxBackups int
+	file       *os.File
+	size       int64
+	config     rotateConfig
+	compressed sync.WaitGroup // backups being compressed
+}
+
+func openRotatingFile(path string, maxBytes int64, maxBackups int) (*rotatingFile, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("log: invalid maximum file size %d", maxBytes)
+	}
+	if maxBackups < 0 {
+		return nil, fmt.Errorf("log: invalid number of backups %d", maxBackups)
+	}
+	r := &rotatingFile{path: path, maxBytes: maxBytes, maxBackups: maxBackups, config: rotateConfig{now: time.Now}}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.file, r.size = f, fi.Size()
+	return nil
+}
+
+// Write writes p, rotating the file first if p would take it past maxBytes. p is never split, so
+// an entry larger than maxBytes is written whole to a fresh file.
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	if r.size > 0 && r.size+int64(len(p)) > r.maxBytes {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate closes the current file, shifts path.1 to path.2 and so on, dropping the oldest backup,
+// renames path to path.1 and opens a new file at path. Backups are shifted whether or not they
+// have been compressed, after waiting for any compression to finish. Backups older than the
+// maximum age are then removed. If the backups can't be shifted, path is reopened for appending, so
+// that later entries are still written and rotation is tried again.
+func (r *rotatingFile) rotate() error {
+	err := r.file.Close()
+	r.file = nil
+	if err == nil {
+		err = r.shift()
+	}
+	if oerr := r.open(); oerr != nil {
+		return errors.Join(err, oerr)
+	}
+	return err
+}
+
+// shift renames or removes the closed file and its backups for rotate.
+func (r *rotatingFile) shift() error {
+	r.compressed.Wait()
+	if r.maxBackups > 0 {
+		for _, ext := range []string{"", ".gz"} {
+			if err := removeIfExists(r.backup(r.maxBackups) + ext); err != nil {
+				return err
+			}
+			for i := r.maxBackups - 1; i > 0; i-- {
+				if err := renameIfExists(r.backup(i)+ext, r.backup(i+1)+ext); err != nil {
+					return err
+				}
+			}
+		}
+		if err := os.Rename(r.path, r.backup(1)); err != nil {
+			return err
+		}
+		if r.config.maxAge > 0 {
+			r.removeExpired()
+		}
+		if r.config.compress && fileExists(r.backup(1)) {
+			compressBackup(&r.compressed, r.backup(1), r.config.onError)
+		}
+	} else if err := os.Remove(r.path); err != nil {
+		return err
+	}
+	return nil
+}
+
+// removeExpired removes the backups last modified more than the maximum age ago. Errors are passed to
+// the error handler rather than stopping the rotation.
+func (r *rotatingFile) removeExpired() {
+	cutoff := r.config.now().Add(-r.config.maxAge)
+	for i := 1; i <= r.maxBackups; i++ {
+		for _, path := range []string{r.backup(i), r.backup(i) + ".gz"} {
+			fi, err := os.Stat(path)
+			if err == nil && fi.ModTime().Before(cutoff) {
+				err = os.Remove(path)
+			}
+			if err != nil && !os.IsNotExist(err) && r.config.onError != nil {
+				r.config.onError(err)
+			}
+		}
+	}
+}
+
+func (r *rotatingFile) backup(n int) string {
+	return fmt.Sprintf("%s.%d", r.path, n)
+}
+
+func (r *rotatingFile) configure(c rotateConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.config = c
+}
+
+// Sync commits the current file to stable storage.
+func (r *rotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Sync()
+}
+
+// Close closes the file and waits for any backups to be compressed.
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	defer r.compressed.Wait()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// timeRotatingFile is a file that is switched for a new one at the start of each period, named by
+// formatting the start of the period with the file name of template as a time layout.
+type timeRotatingFile struct {
+	mu         sync.Mutex
+	template   string
+	interval   time.Duration
+	config     rotateConfig
+	file       *os.File
+	next       time.Time // the end of the current period
+	closed     bool
+	compressed sync.WaitGroup // previous files being compressed
+}
+
+func openTimeRotatingFile(template string, interval time.Duration, now func() time.Time) (*timeRotatingFile, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("log: invalid rotation interval %s", interval)
+	}
+	r := &timeRotatingFile{template: template, interval: interval, config: rotateConfig{now: now}}
+	if err := r.open(now()); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open opens the file for the period containing t, closing the current file if there is one, and
+// compressing it if that is set and the new period has a different file.
+func (r *timeRotatingFile) open(t time.Time) error {
+	start := t.UTC().Truncate(r.interval)
+	dir, name := filepath.Split(r.template)
+	f, err := openFile(dir + start.Format(name))
+	if err != nil {
+		return err
+	}
+	if old := r.file; old != nil {
+		old.Close()
+		if r.config.compress && old.Name() != f.Name() {
+			compressBackup(&r.compressed, old.Name(), r.config.onError)
+		}
+	}
+	r.file, r.next = f, start.Add(r.interval)
+	return nil
+}
+
+// Write writes p to the file for the current period, switching files first if the period has ended.
+func (r *timeRotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+	if t := r.config.now(); !t.Before(r.next) {
+		if err := r.open(t); err != nil {
+			return 0, err
+		}
+	}
+	return r.file.Write(p)
+}
+
+func (r *timeRotatingFile) configure(c rotateConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.config = c
+}
+
+// Sync commits the current file to stable storage.
+func (r *timeRotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	return r.file.Sync()
+}
+
+// Close closes the file and waits for any previous files to be compressed.
+func (r *timeRotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	defer r.compressed.Wait()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	return r.file.Close()
+}
+
+// compressBackup gzips the file at path to path.gz in the background, tracked by wg, and then
+// removes it. Errors are passed to onError, if it isn't nil. Files that already have a .gz
+// extension are left alone.
+func compressBackup(wg *sync.WaitGroup, path string, onError func(error)) {
+	if strings.HasSuffix(path, ".gz") {
+		return
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := gzipFile(path); err != nil && onError != nil {
+			onError(err)
+		}
+	}()
+}
+
+// gzipFile compresses path to path.gz and removes path.
+func gzipFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := dst.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(dst.Name())
+		}
+	}()
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	src.Close()
+	return os.Remove(path)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func renameIfExists(from, to string) error {
+	if err := os.Rename(from, to); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (r *rotatingFile) describe() string {
+	return "rotating file " + r.path
+}
+
+func (r *timeRotatingFile) describe() string {
+	return "rotating file " + r.template
+}
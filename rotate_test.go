@@ -0,0 +1,303 @@
+package log_test
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Could not read %s: %s", path, err)
+	}
+	return string(b)
+}
+
+func TestSetOutputRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l := log.NewLog()
+	l.SetTimestamp(func() string { return "ts" })
+	// each entry is len("ts\tINFO\tentry N\n") == 16 bytes
+	if err := l.SetOutputRotatingFile(path, 40, 2); err != nil {
+		t.Fatalf("Could not open rotating file: %s", err)
+	}
+	defer l.Close()
+	for i := 1; i <= 8; i++ {
+		if err := l.Info("entry %d", i); err != nil {
+			t.Fatalf("Could not write entry %d: %s", i, err)
+		}
+	}
+	if got, want := readFile(t, path), "ts\tINFO\tentry 7\nts\tINFO\tentry 8\n"; got != want {
+		t.Errorf("%s: got %q, want %q", path, got, want)
+	}
+	if got, want := readFile(t, path+".1"), "ts\tINFO\tentry 5\nts\tINFO\tentry 6\n"; got != want {
+		t.Errorf("%s.1: got %q, want %q", path, got, want)
+	}
+	if got, want := readFile(t, path+".2"), "ts\tINFO\tentry 3\nts\tINFO\tentry 4\n"; got != want {
+		t.Errorf("%s.2: got %q, want %q", path, got, want)
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Too many backups kept: %v", err)
+	}
+}
+
+func TestSetOutputRotatingFileLargeEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l := log.NewLog()
+	l.SetTimestamp(func() string { return "ts" })
+	if err := l.SetOutputRotatingFile(path, 20, 1); err != nil {
+		t.Fatalf("Could not open rotating file: %s", err)
+	}
+	defer l.Close()
+	big := strings.Repeat("x", 50)
+	l.Info("small")
+	l.Info("%s", big)
+	if got, want := readFile(t, path), "ts\tINFO\t"+big+"\n"; got != want {
+		t.Errorf("Large entry wasn't written whole: got %q, want %q", got, want)
+	}
+	if got, want := readFile(t, path+".1"), "ts\tINFO\tsmall\n"; got != want {
+		t.Errorf("%s.1: got %q, want %q", path, got, want)
+	}
+}
+
+func TestSetOutputRotatingFileRenameError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l := log.NewLog()
+	l.SetTimestamp(func() string { return "ts" })
+	if err := l.SetOutputRotatingFile(path, 40, 2); err != nil {
+		t.Fatalf("Could not open rotating file: %s", err)
+	}
+	defer l.Close()
+	for i := 1; i <= 2; i++ {
+		if err := l.Info("entry %d", i); err != nil {
+			t.Fatalf("Could not write entry %d: %s", i, err)
+		}
+	}
+	// renaming the file for the next rotation fails, since it is gone
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Info("entry 3"); err == nil {
+		t.Error("Expected a rotation error")
+	}
+	for i := 4; i <= 5; i++ {
+		if err := l.Info("entry %d", i); err != nil {
+			t.Fatalf("Could not write entry %d after a failed rotation: %s", i, err)
+		}
+	}
+	if got, want := readFile(t, path), "ts\tINFO\tentry 4\nts\tINFO\tentry 5\n"; got != want {
+		t.Errorf("%s: got %q, want %q", path, got, want)
+	}
+}
+
+func TestSetOutputRotatingFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l := log.NewLog()
+	for _, c := range []struct {
+		maxBytes   int64
+		maxBackups int
+	}{{0, 1}, {-1, 1}, {40, -1}} {
+		if err := l.SetOutputRotatingFile(path, c.maxBytes, c.maxBackups); err == nil {
+			t.Errorf("Expected an error for maxBytes %d and maxBackups %d", c.maxBytes, c.maxBackups)
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Log file created for invalid settings: %v", err)
+	}
+}
+
+func TestSetOutputRotatingByTime(t *testing.T) {
+	dir := t.TempDir()
+	interval := 200 * time.Millisecond
+	l := log.NewLog()
+	l.SetTimestamp(func() string { return "ts" })
+	if err := l.SetOutputRotatingByTime(filepath.Join(dir, "app-150405.000.log"), interval); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	// start just after a boundary, so the first entries are in the same interval
+	time.Sleep(time.Until(time.Now().Truncate(interval).Add(interval + 10*time.Millisecond)))
+	l.Info("first")
+	l.Info("second")
+	time.Sleep(time.Until(time.Now().Truncate(interval).Add(interval + 10*time.Millisecond)))
+	l.Info("third")
+	files, err := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var contents []string
+	for _, f := range files {
+		if c := readFile(t, f); c != "" {
+			contents = append(contents, c)
+		}
+	}
+	want := []string{"ts\tINFO\tfirst\nts\tINFO\tsecond\n", "ts\tINFO\tthird\n"}
+	if fmt.Sprint(contents) != fmt.Sprint(want) {
+		t.Errorf("Got files %q, want %q", contents, want)
+	}
+	if err := l.SetOutputRotatingByTime(filepath.Join(dir, "bad.log"), 0); err == nil {
+		t.Error("Expected an error for a zero interval")
+	}
+}
+
+// fakeClock is a clock for SetClock that only moves when it is advanced.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestSetOutputRotatingByTimeClock(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 23, 59, 59, 0, time.UTC)}
+	l := log.NewLog()
+	l.SetClock(clock.Now)
+	if err := l.SetOutputRotatingByTime(filepath.Join(dir, "app-2006-01-02.log"), 24*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.Info("before midnight")
+	clock.Advance(time.Second)
+	l.Info("after midnight")
+	if got, want := readFile(t, filepath.Join(dir, "app-2024-03-01.log")), "2024-03-01T23:59:59Z\tINFO\tbefore midnight\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := readFile(t, filepath.Join(dir, "app-2024-03-02.log")), "2024-03-02T00:00:00Z\tINFO\tafter midnight\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// readGzip returns the decompressed contents of the gzip file at path.
+func readGzip(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("%s: %s", path, err)
+	}
+	b, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("%s: %s", path, err)
+	}
+	return string(b)
+}
+
+func TestSetCompressBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l := log.NewLog()
+	l.SetTimestamp(func() string { return "ts" })
+	l.SetCompressBackups(true)
+	if err := l.SetOutputRotatingFile(path, 40, 2); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 6; i++ {
+		l.Info("entry %d", i)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readFile(t, path), "ts\tINFO\tentry 5\nts\tINFO\tentry 6\n"; got != want {
+		t.Errorf("%s: got %q, want %q", path, got, want)
+	}
+	if got, want := readGzip(t, path+".1.gz"), "ts\tINFO\tentry 3\nts\tINFO\tentry 4\n"; got != want {
+		t.Errorf("%s.1.gz: got %q, want %q", path, got, want)
+	}
+	if got, want := readGzip(t, path+".2.gz"), "ts\tINFO\tentry 1\nts\tINFO\tentry 2\n"; got != want {
+		t.Errorf("%s.2.gz: got %q, want %q", path, got, want)
+	}
+	for _, p := range []string{path + ".1", path + ".2", path + ".3.gz"} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s: expected no file, got %v", p, err)
+		}
+	}
+}
+
+func TestSetCompressBackupsByTime(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	l := log.NewLog()
+	l.SetClock(clock.Now)
+	l.SetTimestamp(func() string { return "ts" })
+	if err := l.SetOutputRotatingByTime(filepath.Join(dir, "app-2006-01-02.log"), 24*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	l.SetCompressBackups(true)
+	l.Info("day one")
+	clock.Advance(24 * time.Hour)
+	l.Info("day two")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readGzip(t, filepath.Join(dir, "app-2024-03-01.log.gz")), "ts\tINFO\tday one\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := readFile(t, filepath.Join(dir, "app-2024-03-02.log")), "ts\tINFO\tday two\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app-2024-03-01.log")); !os.IsNotExist(err) {
+		t.Errorf("Uncompressed file wasn't removed: %v", err)
+	}
+}
+
+func TestSetMaxAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	clock := &fakeClock{now: time.Now()}
+	for name, age := range map[string]time.Duration{path + ".1": 48 * time.Hour, path + ".2.gz": time.Hour} {
+		if err := os.WriteFile(name, []byte("old\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := clock.Now().Add(-age)
+		if err := os.Chtimes(name, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var handled []error
+	l := log.NewLog()
+	l.SetClock(clock.Now)
+	l.SetErrorHandler(func(err error) { handled = append(handled, err) })
+	l.SetMaxAge(24 * time.Hour)
+	l.SetTimestamp(func() string { return "ts" })
+	if err := l.SetOutputRotatingFile(path, 20, 5); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.Info("entry 1")
+	l.Info("entry 2") // rotates
+	if _, err := os.Stat(path + ".2"); !os.IsNotExist(err) {
+		t.Errorf("Expired backup wasn't removed: %v", err)
+	}
+	if got, want := readFile(t, path+".3.gz"), "old\n"; got != want {
+		t.Errorf("Recent backup: got %q, want %q", got, want)
+	}
+	if got, want := readFile(t, path+".1"), "ts\tINFO\tentry 1\n"; got != want {
+		t.Errorf("New backup: got %q, want %q", got, want)
+	}
+	if handled != nil {
+		t.Errorf("Unexpected errors: %v", handled)
+	}
+}
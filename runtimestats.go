@@ -0,0 +1,49 @@
+package log
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// defaultRuntimeStatsInterval is the interval StartRuntimeStats uses when it is given an invalid one.
+const defaultRuntimeStatsInterval = time.Minute
+
+// StartRuntimeStats writes an entry with memory, goroutine and GC statistics to l every interval,
+// at the given level (a single Level bit, eg. LevelDebug). The statistics are gathered in a background
+// goroutine; call the returned stop function to halt it. stop waits for the goroutine to exit and may
+// be called more than once. An interval of zero or less is replaced by defaultRuntimeStatsInterval
+// rather than making the goroutine panic.
+func StartRuntimeStats(l *Log, interval time.Duration, level Level) (stop func()) {
+	if interval <= 0 {
+		interval = defaultRuntimeStatsInterval
+	}
+	write := l.logFunc(level)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var ms runtime.MemStats
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				runtime.ReadMemStats(&ms)
+				write("runtime stats: alloc=%d heap_alloc=%d heap_objects=%d goroutines=%d num_gc=%d gc_pause_total=%s gc_pause_last=%s",
+					ms.Alloc, ms.HeapAlloc, ms.HeapObjects, runtime.NumGoroutine(), ms.NumGC,
+					time.Duration(ms.PauseTotalNs), time.Duration(ms.PauseNs[(ms.NumGC+255)%256]))
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package log_test
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Syncbak-Git/log"
+)
+
+// syncBuffer is a bytes.Buffer that is safe to write from a background goroutine.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestStartRuntimeStats(t *testing.T) {
+	var buff syncBuffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	stop := log.StartRuntimeStats(l, 5*time.Millisecond, log.LevelDebug)
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(buff.String(), "runtime stats:") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+	b := buff.String()
+	if !strings.Contains(b, "DEBUG\truntime stats:") {
+		t.Fatalf("No runtime stats entry written: %s", b)
+	}
+	for _, k := range []string{"alloc=", "heap_alloc=", "goroutines=", "gc_pause_total="} {
+		if !strings.Contains(b, k) {
+			t.Errorf("Runtime stats entry missing %q: %s", k, b)
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	if a := buff.String(); a != b {
+		t.Errorf("Entries written after stop: %s", strings.TrimPrefix(a, b))
+	}
+	stop() // a second stop is a no-op
+}
+
+func TestStartRuntimeStatsZeroInterval(t *testing.T) {
+	var buff syncBuffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	stop := log.StartRuntimeStats(l, 0, log.LevelDebug)
+	time.Sleep(10 * time.Millisecond)
+	stop()
+	if b := buff.String(); b != "" {
+		t.Errorf("Expected no entries within a minute, got %s", b)
+	}
+}
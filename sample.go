@@ -0,0 +1,122 @@
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sampler decides which entries are written. Sample is called for each enabled entry with its Level
+// and unformatted format string, so all entries logged with the same template are treated alike, and
+// the entry is suppressed if it returns false. Entries that have no format string, such as those
+// written by Infoln or by Writer and LineWriter, are passed their message instead. Sample may be
+// called concurrently.
+type Sampler interface {
+	Sample(level Level, format string) bool
+}
+
+// RepeatSampler is a Sampler that limits repeated entries. Within each window, it lets through the
+// first entries for each level and format string, then only every thereafter-th one.
+type RepeatSampler struct {
+	first      int
+	thereafter int
+	window     time.Duration
+
+	mu     sync.Mutex
+	now    func() time.Time
+	start  time.Time
+	counts map[sampleKey]int
+}
+
+type sampleKey struct {
+	level  Level
+	format string
+}
+
+// NewRepeatSampler returns a RepeatSampler that writes the first entries for each level and format
+// string in every window, and after that every thereafter-th entry. A thereafter of zero or less
+// suppresses all entries after the first ones until the window ends.
+func NewRepeatSampler(first, thereafter int, window time.Duration) *RepeatSampler {
+	return &RepeatSampler{first: first, thereafter: thereafter, window: window, now: time.Now}
+}
+
+func (s *RepeatSampler) Sample(level Level, format string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now := s.now(); s.counts == nil || now.Sub(s.start) >= s.window {
+		s.start = now
+		s.counts = make(map[sampleKey]int) // forget the templates of the last window
+	}
+	k := sampleKey{level: level, format: format}
+	s.counts[k]++
+	n := s.counts[k] - s.first
+	return n <= 0 || (s.thereafter > 0 && n%s.thereafter == 0)
+}
+
+// clone returns a new RepeatSampler with the same settings as s, which hasn't seen any entries.
+func (s *RepeatSampler) clone() *RepeatSampler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &RepeatSampler{first: s.first, thereafter: s.thereafter, window: s.window, now: s.now}
+}
+
+func (s *RepeatSampler) setClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// SetSampler makes l pass the entries it writes with a format string through s, and suppress those
+// that s rejects. FATAL and PANIC entries, and entries written by the Func variants, are never
+// sampled. The number of suppressed entries is returned by Sampled, and FlushSampled writes it as a
+// summary entry. A nil s disables sampling.
+func (l *Log) SetSampler(s Sampler) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if c, ok := s.(clockSetter); ok {
+		c.setClock(l.clock)
+	}
+	l.sampler = s
+}
+
+// Sampled returns the number of entries suppressed by the Sampler since the last FlushSampled.
+func (l *Log) Sampled() uint64 {
+	if l == nil {
+		return 0
+	}
+	return atomic.LoadUint64(l.sampled)
+}
+
+// FlushSampled writes an INFO entry with a "suppressed" field holding the number of entries
+// suppressed by the Sampler since the last call, and resets the count. It writes nothing if no
+// entries were suppressed. The summary entry itself is never sampled.
+func (l *Log) FlushSampled() error {
+	if l == nil {
+		return nil
+	}
+	n := atomic.SwapUint64(l.sampled, 0)
+	if n == 0 || !l.Enabled(LevelInfo) {
+		return nil
+	}
+	summary := l.WithFields(map[string]interface{}{"suppressed": n})
+	return summary.writeMessage(LevelInfo, "INFO", "suppressed sampled entries", false)
+}
+
+// sample reports whether an entry with level and format should be written, counting it as suppressed
+// if it shouldn't.
+func (l *Log) sample(level Level, format string) bool {
+	if level&(LevelFatal|LevelPanic) != 0 {
+		return true
+	}
+	l.mu.Lock()
+	s := l.sampler
+	l.mu.Unlock()
+	if s == nil || s.Sample(level, format) {
+		return true
+	}
+	atomic.AddUint64(l.sampled, 1)
+	return false
+}
@@ -0,0 +1,119 @@
+package log_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func TestRepeatSampler(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	l.SetSampler(log.NewRepeatSampler(10, 100, time.Hour))
+	for i := 0; i < 1000; i++ {
+		l.Error("request %d failed", i)
+	}
+	l.Info("different template")
+	if got := strings.Count(buff.String(), "failed"); got != 19 {
+		t.Errorf("Wrote %d of 1000 identical entries, want 19", got)
+	}
+	if !strings.Contains(buff.String(), "request 109 failed") || !strings.Contains(buff.String(), "different template") {
+		t.Errorf("Expected entries are missing: %s", buff.String())
+	}
+	if got := l.Sampled(); got != 981 {
+		t.Errorf("Sampled() = %d, want 981", got)
+	}
+	buff.Reset()
+	if err := l.FlushSampled(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buff.String(), "ts\tINFO\tsuppressed sampled entries\tsuppressed=981\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	buff.Reset()
+	l.FlushSampled()
+	if buff.Len() != 0 || l.Sampled() != 0 {
+		t.Errorf("Unexpected summary after reset: %q", buff.String())
+	}
+}
+
+func TestRepeatSamplerWindow(t *testing.T) {
+	s := log.NewRepeatSampler(1, 0, 20*time.Millisecond)
+	if !s.Sample(log.LevelInfo, "x") || s.Sample(log.LevelInfo, "x") {
+		t.Fatal("Expected only the first entry to be sampled")
+	}
+	if !s.Sample(log.LevelError, "x") {
+		t.Error("Levels should be sampled separately")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if !s.Sample(log.LevelInfo, "x") {
+		t.Error("Expected the count to reset after the window")
+	}
+}
+
+func TestRepeatSamplerClock(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetSampler(log.NewRepeatSampler(1, 0, time.Minute))
+	l.SetClock(clock.Now)
+	l.Info("tick")
+	clock.Advance(59 * time.Second)
+	l.Info("tick")
+	clock.Advance(time.Second)
+	l.Info("tick")
+	if got := strings.Count(buff.String(), "tick"); got != 2 {
+		t.Errorf("Wrote %d entries, want 2: %s", got, buff.String())
+	}
+}
+
+func TestRepeatSamplerLines(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetSampler(log.NewRepeatSampler(1, 0, time.Hour))
+	l.Infoln("a")
+	l.Infoln("disk full")
+	l.Infoln("a")
+	w := l.Writer(log.LevelInfo)
+	io.WriteString(w, "from writer\n")
+	lw := l.LineWriter(log.LevelInfo)
+	io.WriteString(lw, "from line writer\nfrom line writer\n")
+	lw.Close()
+	for _, msg := range []string{"\ta\n", "\tdisk full\n", "\tfrom writer\n", "\tfrom line writer\n"} {
+		if got := strings.Count(buff.String(), msg); got != 1 {
+			t.Errorf("Wrote %q %d times, want once: %s", msg, got, buff.String())
+		}
+	}
+	if got := l.Sampled(); got != 2 {
+		t.Errorf("Sampled() = %d, want 2", got)
+	}
+}
+
+func TestFatalAsErrorLines(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetFatalAsError(true)
+	l.SetStackTraceLevel(log.LevelNone)
+	l.SetSampler(log.NewRepeatSampler(1, 0, time.Hour))
+	l.SetDedup(time.Hour)
+	l.Fatalln("disk full")
+	l.Fatalln("out of memory")
+	l.Panicln("bad state")
+	w := l.Writer(log.LevelFatal)
+	io.WriteString(w, "from writer\n")
+	l.Close()
+	for _, msg := range []string{"FATAL\tdisk full\n", "FATAL\tout of memory\n", "PANIC\tbad state\n", "FATAL\tfrom writer\n"} {
+		if !strings.Contains(buff.String(), msg) {
+			t.Errorf("Missing %q: %s", msg, buff.String())
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Sink receives each entry as its parts, rather than as a line rendered by the Log's Formatter, so that
+// a transport that frames or encodes entries itself, such as a syslog or GELF client, gets the entry's
+// Level and structured fields. level is the entry's Level bit and name the level name a Formatter would
+// write, which differs from level's String for Custom entries. The fields map is shared with the Log and
+// must not be modified or retained.
+//
+// A Sink replaces the output rather than being added to it. Code that only has an io.Writer keeps using
+// SetOutput, which is unchanged. To migrate, wrap the writer with NewWriterSink, which formats entries
+// exactly as SetOutput does, and then replace it with a Sink of your own when you need the parts.
+type Sink interface {
+	Write(level Level, name, timestamp, message string, fields map[string]interface{}) error
+	Close() error
+}
+
+// SetSink directs entries to s instead of the output set by SetOutput. Entries are passed to s without
+// being formatted, so the Formatter and SetFraming don't apply to them. Close closes s.
+func (l *Log) SetSink(s Sink) {
+	l.SetOutput(sinkOutput{s})
+}
+
+// NewWriterSink returns a Sink that formats each entry with f and writes it, followed by a newline, to
+// w. Close closes w, if it is an io.Closer.
+func NewWriterSink(w io.Writer, f Formatter) Sink {
+	return &writerSink{w: w, f: f}
+}
+
+type writerSink struct {
+	w io.Writer
+	f Formatter
+}
+
+func (s *writerSink) Write(level Level, name, timestamp, message string, fields map[string]interface{}) error {
+	b, err := s.f.Format(timestamp, name, message, fields)
+	if err != nil {
+		return err
+	}
+	_, err = s.w.Write(append(b, '\n'))
+	return err
+}
+
+func (s *writerSink) Close() error {
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// errSinkOutput is returned when a rendered line is written to a Sink, eg. because it was combined with
+// other outputs by AddOutput.
+var errSinkOutput = errors.New("log: a Sink can't be written formatted entries")
+
+// sinkOutput stores a Sink as the Log's output. newEntry recognizes it and leaves the entry unformatted.
+type sinkOutput struct {
+	Sink
+}
+
+func (s sinkOutput) Write(p []byte) (int, error) {
+	return 0, errSinkOutput
+}
+
+// Flush flushes the Sink, if it buffers.
+func (s sinkOutput) Flush() error {
+	if f, ok := s.Sink.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (s sinkOutput) describe() string {
+	return fmt.Sprintf("sink %T", s.Sink)
+}
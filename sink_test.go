@@ -0,0 +1,70 @@
+package log_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Syncbak-Git/log"
+)
+
+// recordingSink records the entries written to it.
+type recordingSink struct {
+	entries []string
+	closed  bool
+}
+
+func (s *recordingSink) Write(level log.Level, name, timestamp, message string, fields map[string]interface{}) error {
+	s.entries = append(s.entries, fmt.Sprintf("%s %s %s %s %v", level, name, timestamp, message, fields))
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestSetSink(t *testing.T) {
+	var s recordingSink
+	l := log.NewLog()
+	l.SetClock(func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) })
+	l.SetSink(&s)
+	l = l.With("host", "a")
+	l.Error("disk %d full", 1)
+	l.Custom("AUDIT", "login")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"ERROR ERROR 2024-01-02T03:04:05Z disk 1 full map[host:a]",
+		"CUSTOM AUDIT 2024-01-02T03:04:05Z login map[host:a]",
+	}
+	if fmt.Sprint(s.entries) != fmt.Sprint(want) {
+		t.Errorf("got %q, want %q", s.entries, want)
+	}
+	if !s.closed {
+		t.Error("Close didn't close the sink")
+	}
+}
+
+func TestNewWriterSink(t *testing.T) {
+	var direct, sunk bytes.Buffer
+	clock := func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+	for _, c := range []struct {
+		buff *bytes.Buffer
+		set  func(*log.Log)
+	}{
+		{&direct, func(l *log.Log) { l.SetOutput(&direct) }},
+		{&sunk, func(l *log.Log) { l.SetSink(log.NewWriterSink(&sunk, &log.JSONFormatter{})) }},
+	} {
+		l := log.NewLog()
+		l.SetClock(clock)
+		l.SetFormat(&log.JSONFormatter{})
+		c.set(l)
+		l.With("user", "bob").Info("hello")
+	}
+	if direct.String() != sunk.String() {
+		t.Errorf("got %q from the sink, want %q", sunk.String(), direct.String())
+	}
+}
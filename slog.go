@@ -0,0 +1,105 @@
+//go:build go1.21
+
+package log
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogHandler returns a slog.Handler that writes slog records to l, so that slog can be used as the
+// front end while l's levels, formatting and outputs still apply:
+//
+//	logger := slog.New(l.SlogHandler())
+//
+// slog's Debug, Info, Warn and Error levels are written as DEBUG, INFO, WARNING and ERROR entries.
+// Any other slog level is written as a Custom entry named by the level's String, eg. "INFO+2", and
+// is enabled by LevelCustom. The record's attributes become fields of the entry, as do those added by
+// WithAttrs, and the attributes of a group, whether from WithGroup or a group attribute, are written
+// with the group's name and a dot prepended to their keys, eg. "request.id". The fields carried by the
+// context, as for the XXXCtx methods, are written too. The record's message is written as is, and its
+// time is replaced by l's timestamp.
+func (l *Log) SlogHandler() slog.Handler {
+	return &slogHandler{l: l}
+}
+
+// slogHandler implements slog.Handler for a Log.
+type slogHandler struct {
+	l     *Log
+	group string // the prefix for attribute keys, eg. "request.", from WithGroup
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	ll, _ := slogLevel(level)
+	return h.l.Enabled(ll)
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	level, name := slogLevel(r.Level)
+	if !h.l.Enabled(level) || !h.l.sample(level, r.Message) {
+		return nil
+	}
+	l := h.l.withContext(ctx)
+	if r.NumAttrs() > 0 {
+		fields := make(map[string]interface{}, r.NumAttrs())
+		r.Attrs(func(a slog.Attr) bool {
+			addSlogAttr(fields, h.group, a)
+			return true
+		})
+		l = l.WithFields(fields)
+	}
+	return l.writeMessage(level, name, r.Message, false)
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	fields := make(map[string]interface{}, len(attrs))
+	for _, a := range attrs {
+		addSlogAttr(fields, h.group, a)
+	}
+	return &slogHandler{l: h.l.WithFields(fields), group: h.group}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{l: h.l, group: h.group + name + "."}
+}
+
+// slogLevel returns the Level bit and level name for a slog level.
+func slogLevel(level slog.Level) (Level, string) {
+	switch level {
+	case slog.LevelDebug:
+		return LevelDebug, "DEBUG"
+	case slog.LevelInfo:
+		return LevelInfo, "INFO"
+	case slog.LevelWarn:
+		return LevelWarning, "WARNING"
+	case slog.LevelError:
+		return LevelError, "ERROR"
+	}
+	return LevelCustom, level.String()
+}
+
+// addSlogAttr adds a to fields, with group prepended to its key. The attributes of a group attribute
+// are added individually, and empty attributes and groups are ignored, as slog.Handler requires.
+func addSlogAttr(fields map[string]interface{}, group string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		prefix := group
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			addSlogAttr(fields, prefix, ga)
+		}
+		return
+	}
+	if a.Key == "" && v.Any() == nil {
+		return
+	}
+	fields[group+a.Key] = v.Any()
+}
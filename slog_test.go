@@ -0,0 +1,40 @@
+//go:build go1.21
+
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	l.SetLogLevel(log.LevelAll ^ log.LevelDebug)
+	logger := slog.New(l.SlogHandler())
+
+	logger.Debug("hidden")
+	logger.Info("plain %s")
+	logger.With("service", "api").WithGroup("req").With("id", 7).Warn("slow",
+		"ms", 1200, slog.Group("user", "name", "bob"), slog.Group("empty"))
+	logger.Error("failed", "err", "timeout", slog.Group("", "inline", true))
+	logger.Log(context.Background(), slog.LevelInfo+2, "custom")
+	want := "ts\tINFO\tplain %s\n" +
+		"ts\tWARNING\tslow\treq.id=7 req.ms=1200 req.user.name=bob service=api\n" +
+		"ts\tERROR\tfailed\terr=timeout inline=true\n" +
+		"ts\tINFO+2\tcustom\n"
+	if got := buff.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	h := l.SlogHandler()
+	if h.Enabled(context.Background(), slog.LevelDebug) || !h.Enabled(context.Background(), slog.LevelError) {
+		t.Error("Expected the handler to follow the log level")
+	}
+}
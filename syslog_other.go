@@ -0,0 +1,9 @@
+//go:build windows || plan9
+
+package log
+
+import "io"
+
+func openSyslog(tag string) (io.WriteCloser, error) {
+	return nil, ErrSyslogUnsupported
+}
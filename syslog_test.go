@@ -0,0 +1,30 @@
+package log_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func TestSetSyslogOutput(t *testing.T) {
+	l := log.NewLog()
+	err := l.SetSyslogOutput("log_test")
+	switch {
+	case runtime.GOOS == "windows" || runtime.GOOS == "plan9":
+		if err != log.ErrSyslogUnsupported {
+			t.Errorf("Expected ErrSyslogUnsupported, got %v", err)
+		}
+		return
+	case err != nil:
+		t.Skipf("No syslog daemon available: %s", err)
+	}
+	for _, write := range []func(string, ...interface{}) error{l.Debug, l.Info, l.Warning, l.Error} {
+		if err := write("Hello %s", "syslog"); err != nil {
+			t.Errorf("Could not write to syslog: %s", err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("Could not close syslog: %s", err)
+	}
+}
@@ -0,0 +1,48 @@
+//go:build !windows && !plan9
+
+package log
+
+import (
+	"io"
+	"log/syslog"
+)
+
+// syslogOutput writes entries to syslog with the severity of their level.
+type syslogOutput struct {
+	w *syslog.Writer
+}
+
+func openSyslog(tag string) (io.WriteCloser, error) {
+	w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogOutput{w: w}, nil
+}
+
+func (s *syslogOutput) Write(p []byte) (int, error) {
+	return s.w.Write(p)
+}
+
+func (s *syslogOutput) writeLevel(level Level, p []byte) error {
+	m := string(p)
+	switch level {
+	case LevelTrace, LevelDebug:
+		return s.w.Debug(m)
+	case LevelWarning:
+		return s.w.Warning(m)
+	case LevelError:
+		return s.w.Err(m)
+	case LevelFatal, LevelPanic:
+		return s.w.Crit(m)
+	}
+	return s.w.Info(m)
+}
+
+func (s *syslogOutput) Close() error {
+	return s.w.Close()
+}
+
+func (s *syslogOutput) describe() string {
+	return "syslog"
+}
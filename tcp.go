@@ -0,0 +1,71 @@
+package log
+
+import (
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// tcpOutput writes entries to a TCP connection, redialing after a failed write.
+type tcpOutput struct {
+	mu          sync.Mutex
+	addr        string
+	dialTimeout time.Duration
+	conn        net.Conn
+	closed      bool
+}
+
+func dialTCP(addr string, dialTimeout time.Duration) (*tcpOutput, error) {
+	t := &tcpOutput{addr: addr, dialTimeout: dialTimeout}
+	if err := t.dial(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *tcpOutput) dial() error {
+	c, err := net.DialTimeout("tcp", t.addr, t.dialTimeout)
+	if err != nil {
+		return err
+	}
+	t.conn = c
+	return nil
+}
+
+// Write writes p to the connection, first redialing if the previous write failed. A failed write
+// drops the connection and returns the error; it doesn't retry.
+func (t *tcpOutput) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return 0, os.ErrClosed
+	}
+	if t.conn == nil {
+		if err := t.dial(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := t.conn.Write(p)
+	if err != nil {
+		t.conn.Close()
+		t.conn = nil
+	}
+	return n, err
+}
+
+func (t *tcpOutput) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	if t.conn == nil {
+		return nil
+	}
+	err := t.conn.Close()
+	t.conn = nil
+	return err
+}
+
+func (t *tcpOutput) describe() string {
+	return "tcp " + t.addr
+}
@@ -0,0 +1,78 @@
+package log_test
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func TestSetTCPOutput(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not listen: %s", err)
+	}
+	defer ln.Close()
+	conns := make(chan net.Conn, 2)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns <- c
+		}
+	}()
+	l := log.NewLog()
+	l.SetTimestamp(func() string { return "ts" })
+	if err := l.SetTCPOutput(ln.Addr().String(), time.Second); err != nil {
+		t.Fatalf("SetTCPOutput failed: %s", err)
+	}
+	defer l.Close()
+	first := <-conns
+	if err := l.Info("first"); err != nil {
+		t.Fatalf("Could not write entry: %s", err)
+	}
+	line, err := bufio.NewReader(first).ReadString('\n')
+	if err != nil || line != "ts\tINFO\tfirst\n" {
+		t.Fatalf("Bad entry %q: %v", line, err)
+	}
+	// drop the connection; writes fail until the logger notices, and then the next write redials
+	first.Close()
+	var failed bool
+	for i := 0; i < 100 && !failed; i++ {
+		failed = l.Info("lost") != nil
+		time.Sleep(time.Millisecond)
+	}
+	if !failed {
+		t.Fatal("Writes to a closed connection never failed")
+	}
+	if err := l.Info("second"); err != nil {
+		t.Fatalf("Write didn't reconnect: %s", err)
+	}
+	var second net.Conn
+	select {
+	case second = <-conns:
+	case <-time.After(5 * time.Second):
+		t.Fatal("No reconnection")
+	}
+	defer second.Close()
+	line, err = bufio.NewReader(second).ReadString('\n')
+	if err != nil || line != "ts\tINFO\tsecond\n" {
+		t.Errorf("Bad entry after reconnect %q: %v", line, err)
+	}
+}
+
+func TestSetTCPOutputDialError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not listen: %s", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	if err := log.NewLog().SetTCPOutput(addr, time.Second); err == nil {
+		t.Error("SetTCPOutput to a closed port didn't return an error")
+	}
+}
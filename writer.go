@@ -0,0 +1,132 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Writer returns an io.Writer that writes each line written to it as a separate entry at level, which
+// should be a single Level bit such as LevelWarning. Each Write is treated as complete lines; a single
+// trailing newline is ignored. This lets a standard library logger, or anything else that writes to an
+// io.Writer, write entries to l:
+//
+//	stdlog.SetOutput(l.Writer(log.LevelWarning))
+func (l *Log) Writer(level Level) io.Writer {
+	return &entryWriter{write: l.lineFunc(level)}
+}
+
+// entryWriter writes lines as log entries.
+type entryWriter struct {
+	write func(msg string) error
+}
+
+func (w *entryWriter) Write(p []byte) (int, error) {
+	var err error
+	for _, line := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+		if werr := w.write(line); err == nil {
+			err = werr
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// maxLineWriterLine is the length of the parts that a LineWriter splits longer lines into, so that
+// output without newlines isn't buffered without bound and no entry is longer.
+const maxLineWriterLine = 64 << 10
+
+// LineWriter returns an io.WriteCloser that writes each line written to it as a separate entry at
+// level, which should be a single Level bit such as LevelInfo. Unlike Writer, it doesn't assume each
+// Write holds complete lines: a partial line is buffered until the rest of it is written, and Close
+// writes any final line that doesn't end with a newline. A trailing "\r" is removed from each line, and
+// a line longer than 64KB is written in 64KB parts. This suits the output of a subprocess:
+//
+//	stdout := l.LineWriter(log.LevelInfo)
+//	defer stdout.Close()
+//	cmd := exec.Command("make")
+//	cmd.Stdout = stdout
+//
+// The returned writer is safe for concurrent use. Writes after Close return os.ErrClosed.
+func (l *Log) LineWriter(level Level) io.WriteCloser {
+	return &lineWriter{write: l.lineFunc(level)}
+}
+
+// lineWriter reassembles lines from arbitrary writes and writes them as log entries.
+type lineWriter struct {
+	write func(msg string) error
+
+	mu     sync.Mutex
+	buf    []byte // the partial line written so far
+	closed bool
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	n := len(p)
+	var err error
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			break
+		}
+		w.buf = append(w.buf, p[:i]...)
+		err = w.writeParts(err)
+		err = w.writeLine(err)
+		p = p[i+1:]
+	}
+	w.buf = append(w.buf, p...)
+	err = w.writeParts(err)
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Close writes the final partial line, if there is one.
+func (w *lineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if len(w.buf) == 0 {
+		return nil
+	}
+	return w.writeLine(nil)
+}
+
+// writeParts writes the buffered line in parts of maxLineWriterLine bytes while more than that is
+// buffered, leaving the rest in the buffer. It returns err, or the first error writing a part if err is
+// nil.
+func (w *lineWriter) writeParts(err error) error {
+	n := 0
+	for len(w.buf)-n > maxLineWriterLine {
+		if werr := w.write(string(w.buf[n : n+maxLineWriterLine])); err == nil {
+			err = werr
+		}
+		n += maxLineWriterLine
+	}
+	w.buf = append(w.buf[:0], w.buf[n:]...)
+	return err
+}
+
+// writeLine writes the buffered line as an entry and empties the buffer. It returns err, or the error
+// writing the entry if err is nil.
+func (w *lineWriter) writeLine(err error) error {
+	werr := w.write(string(bytes.TrimSuffix(w.buf, []byte("\r"))))
+	w.buf = w.buf[:0]
+	if err == nil {
+		err = werr
+	}
+	return err
+}
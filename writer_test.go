@@ -0,0 +1,78 @@
+package log_test
+
+import (
+	"bytes"
+	stdlog "log"
+	"strings"
+	"testing"
+
+	"github.com/Syncbak-Git/log"
+)
+
+func TestWriter(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	std := stdlog.New(l.Writer(log.LevelWarning), "", 0)
+	std.Print("Hello world")
+	std.Print("first\nsecond")
+	l.SetLogLevel(log.LevelAll ^ log.LevelDebug)
+	l.Writer(log.LevelDebug).Write([]byte("suppressed\n"))
+	want := "ts\tWARNING\tHello world\n" +
+		"ts\tWARNING\tfirst\n" +
+		"ts\tWARNING\tsecond\n"
+	if got := buff.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLineWriter(t *testing.T) {
+	var buff bytes.Buffer
+	l := log.NewLog()
+	l.SetOutput(&buff)
+	l.SetTimestamp(func() string { return "ts" })
+	w := l.LineWriter(log.LevelInfo)
+	for _, chunk := range []string{"fir", "st\nsec", "ond\r\n", "\nthi", "rd 100%"} {
+		if n, err := w.Write([]byte(chunk)); n != len(chunk) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+		}
+	}
+	want := "ts\tINFO\tfirst\n" +
+		"ts\tINFO\tsecond\n" +
+		"ts\tINFO\t\n"
+	if got := buff.String(); got != want {
+		t.Errorf("before Close got:\n%s\nwant:\n%s", got, want)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want += "ts\tINFO\tthird 100%\n"
+	if got := buff.String(); got != want {
+		t.Errorf("after Close got:\n%s\nwant:\n%s", got, want)
+	}
+	if _, err := w.Write([]byte("late\n")); err == nil {
+		t.Error("Expected an error writing after Close")
+	}
+
+	buff.Reset()
+	w = l.LineWriter(log.LevelWarning)
+	w.Write(bytes.Repeat([]byte("x"), 150<<10))
+	w.Close()
+	lines := strings.Split(strings.TrimSuffix(buff.String(), "\n"), "\n")
+	if len(lines) != 3 || len(lines[0]) != len("ts\tWARNING\t")+64<<10 || len(lines[2]) != len("ts\tWARNING\t")+22<<10 {
+		t.Errorf("Expected a long line to be split into 64KB parts, got %d lines", len(lines))
+	}
+
+	buff.Reset()
+	w = l.LineWriter(log.LevelWarning)
+	w.Write(append(bytes.Repeat([]byte("y"), 200<<10), '\n'))
+	lines = strings.Split(strings.TrimSuffix(buff.String(), "\n"), "\n")
+	if len(lines) != 4 || len(lines[0]) != len("ts\tWARNING\t")+64<<10 || len(lines[3]) != len("ts\tWARNING\t")+8<<10 {
+		t.Errorf("Expected a long complete line to be split into 64KB parts, got %d lines", len(lines))
+	}
+	w.Close()
+	if n := strings.Count(buff.String(), "\n"); n != 4 {
+		t.Errorf("Close wrote %d more lines", n-4)
+	}
+}